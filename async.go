@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultQueueSize is the number of entries the async queue holds when QueueSize is not set.
+const defaultQueueSize = 1024
+
+// asyncQueue hands encoded entries over to a background goroutine that writes
+// them, keeping slow destinations off the caller's path. Entries are written in
+// the order they were queued.
+// It is shared with the loggers derived through With and is safe for concurrent use.
+// closed: Whether the queue has been closed; entries queued afterwards are discarded.
+// items: Entries waiting to be written.
+// drop: Whether entries are dropped instead of blocking the caller when items is full.
+// dropped: Number of entries dropped because items was full.
+// onError: Receives the errors of the background writes.
+// worker: ID of the goroutine writing the entries.
+// reporting: Whether the worker is passing a failure to onError. Entries the
+// ErrorHandler logs meanwhile are tagged so that their own failures are dropped.
+// done: Closed once the worker has written every queued entry and exited.
+type asyncQueue struct {
+	mu        sync.RWMutex
+	closed    bool
+	items     chan asyncItem
+	drop      bool
+	dropped   atomic.Uint64
+	onError   *errorHandler
+	worker    atomic.Uint64
+	reporting atomic.Bool
+	done      chan struct{}
+}
+
+// asyncItem is an encoded entry waiting to be written, or a flush marker.
+// w: Destination of the entry.
+// b: The encoded entry.
+// syncFiles: Files synced to disk once the entry is written.
+// quiet: Whether the entry was logged by the ErrorHandler, in which case a failure to write it is not reported.
+// flushed: Closed when the worker reaches the marker, nil for entries.
+type asyncItem struct {
+	w         io.Writer
+	b         []byte
+	syncFiles []*rotatingFile
+	quiet     bool
+	flushed   chan struct{}
+}
+
+// newAsyncQueue starts an asyncQueue holding up to size entries, or returns nil
+// when asynchronous writing is disabled.
+func newAsyncQueue(enabled bool, size int, drop bool, onError *errorHandler) *asyncQueue {
+	if !enabled {
+		return nil
+	}
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+
+	q := &asyncQueue{
+		items:   make(chan asyncItem, size),
+		drop:    drop,
+		onError: onError,
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// run writes the queued entries until the queue is closed.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	q.worker.Store(goroutineID())
+
+	for item := range q.items {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		q.write(item)
+	}
+}
+
+// write writes item and passes a failure to the ErrorHandler, unless the entry
+// was logged by the ErrorHandler itself.
+func (q *asyncQueue) write(item asyncItem) {
+	err := writeEntry(item.w, item.b, item.syncFiles)
+	if err == nil || item.quiet {
+		return
+	}
+
+	q.reporting.Store(true)
+	defer q.reporting.Store(false)
+	q.onError.report(err)
+}
+
+// onWorker reports whether the caller runs on the worker goroutine.
+func (q *asyncQueue) onWorker() bool {
+	return goroutineID() == q.worker.Load()
+}
+
+// enqueue queues b, an entry at level, to be written to w, blocking while the
+// queue is full unless drop is set, in which case the entry is dropped and
+// counted. FATAL entries are never dropped, so that Fatal does not exit
+// without its message.
+// Entries logged by the ErrorHandler while the worker runs it are tagged as
+// quiet, and written on the spot when the queue is full since the worker
+// cannot make room while it waits for them.
+func (q *asyncQueue) enqueue(level Level, w io.Writer, b []byte, syncFiles []*rotatingFile) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return
+	}
+	item := asyncItem{w: w, b: b, syncFiles: syncFiles}
+	if q.reporting.Load() && q.onWorker() {
+		item.quiet = true
+		select {
+		case q.items <- item:
+		default:
+			q.write(item)
+		}
+		return
+	}
+	if !q.drop || level == FATAL {
+		q.items <- item
+		return
+	}
+	select {
+	case q.items <- item:
+	default:
+		q.dropped.Add(1)
+	}
+}
+
+// wait blocks until every entry queued before the call has been written.
+// It returns at once when called by the ErrorHandler on the worker goroutine,
+// which would otherwise wait for itself.
+func (q *asyncQueue) wait() {
+	if q == nil || q.onWorker() {
+		return
+	}
+
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	q.items <- asyncItem{flushed: flushed}
+	q.mu.RUnlock()
+
+	<-flushed
+}
+
+// close stops accepting entries and waits until the queued ones are written,
+// unless it is called by the ErrorHandler on the worker goroutine.
+func (q *asyncQueue) close() {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.items)
+	}
+	q.mu.Unlock()
+
+	if !q.onWorker() {
+		<-q.done
+	}
+}
+
+// DroppedEntries returns the number of entries dropped because the queue of an
+// Async Logger configured with DropOnFullQueue was full.
+func (l *Logger) DroppedEntries() uint64 {
+	if l == nil || l.async == nil {
+		return 0
+	}
+	return l.async.dropped.Load()
+}
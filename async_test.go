@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAsync(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel, Async: true, QueueSize: 16})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	const goroutines, entries = 10, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				l.Info("queued")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	got := readFile(t, filepath.Join(dir, "app.log"))
+	if n := strings.Count(got, "INFO queued\n"); n != goroutines*entries {
+		t.Errorf("wrote %d entries, want %d", n, goroutines*entries)
+	}
+}
+
+func TestAsyncDropOnFullQueue(t *testing.T) {
+	stuck := blockingWriter{release: make(chan struct{})}
+	l, err := NewLogger(&LogFileConfigs{Output: stuck, Async: true, QueueSize: 1, DropOnFullQueue: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	// The worker blocks on the first entry and the second fills the queue.
+	for i := 0; i < 10; i++ {
+		l.Info("entry")
+	}
+	if got := l.DroppedEntries(); got < 8 {
+		t.Errorf("DroppedEntries = %d, want at least 8", got)
+	}
+
+	close(stuck.release)
+	if err := l.CloseWithTimeout(5 * time.Second); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestAsyncDropOnFullQueueKeepsFatal(t *testing.T) {
+	stuck := blockingWriter{release: make(chan struct{})}
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(&LogFileConfigs{Output: io.MultiWriter(stuck, buf), Async: true, QueueSize: 1, DropOnFullQueue: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+	l.exit = func(int) {}
+
+	for i := 0; i < 10; i++ {
+		l.Info("entry")
+	}
+	dropped := l.DroppedEntries()
+
+	// Fatal waits for room in the full queue instead of dropping its entry.
+	fatal := make(chan struct{})
+	go func() {
+		defer close(fatal)
+		l.Fatal("shutting down")
+	}()
+	close(stuck.release)
+	<-fatal
+
+	if got := l.DroppedEntries(); got != dropped {
+		t.Errorf("DroppedEntries = %d after Fatal, want %d", got, dropped)
+	}
+	if !strings.Contains(buf.String(), "shutting down") {
+		t.Errorf("FATAL entry was not written: %q", buf.String())
+	}
+}
+
+func TestAsyncErrorHandlerLogs(t *testing.T) {
+	for name, finish := range map[string]func(*Logger) error{
+		"Flush": (*Logger).Flush,
+		"Sync":  (*Logger).Sync,
+		"Close": (*Logger).Close,
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The handler logs through the failing Logger and then flushes or closes it.
+			var calls atomic.Int32
+			var l *Logger
+			handler := func(err error) {
+				calls.Add(1)
+				l.Error("write failed", "err", err)
+				finish(l)
+			}
+			l, err := NewLogger(&LogFileConfigs{Output: failingWriter{errors.New("disk full")}, Async: true, ErrorHandler: handler})
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+
+			l.Info("entry")
+			if err := l.CloseWithTimeout(5 * time.Second); err != nil && !errors.Is(err, ErrClosed) {
+				t.Fatalf("Close: %v", err)
+			}
+			if got := calls.Load(); got != 1 {
+				t.Errorf("ErrorHandler called %d times, want 1", got)
+			}
+		})
+	}
+}
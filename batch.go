@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// BatchWriter coalesces the entries written to it into fewer, larger writes to
+// a destination such as a network connection, where sending one packet per
+// entry is wasteful. Entries are held in memory until size bytes have
+// accumulated or interval has elapsed, and are never split across writes.
+// It is independent of BufferSize, which only applies to log files, and is
+// opted into per destination by wrapping the writer given as Output or to
+// WithOutput:
+//
+//	conn, _ := net.Dial("tcp", "logs.example.com:5000")
+//	batch := logger.NewBatchWriter(conn, 64<<10, time.Second)
+//	defer batch.Close()
+//
+// mu: Guards buf, err and closed.
+// w: Destination receiving the batches.
+// size: Number of buffered bytes that triggers a write.
+// buf: Entries waiting for the next write.
+// err: Error of a background write, returned by the next Write.
+// closed: Set by Close, after which writes fail.
+// done: Closed by Close to stop the background flusher, nil without an interval.
+type BatchWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	size   int
+	buf    []byte
+	err    error
+	closed bool
+	done   chan struct{}
+}
+
+// NewBatchWriter returns a BatchWriter writing to w once size bytes are buffered
+// and, when interval is positive, at least every interval. A size of 0 or less
+// leaves the batches to the interval and to Flush.
+func NewBatchWriter(w io.Writer, size int, interval time.Duration) *BatchWriter {
+	b := &BatchWriter{w: w, size: size}
+	if interval > 0 {
+		b.done = make(chan struct{})
+		go b.flushEvery(interval)
+	}
+	return b
+}
+
+// Write appends p to the current batch and writes the batch to the destination
+// once it reaches the configured size.
+// Returns the error of a failed write. A failure of the background flusher is
+// reported by the next Write, which still buffers p and returns len(p).
+func (b *BatchWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, os.ErrClosed
+	}
+
+	b.buf = append(b.buf, p...)
+	if b.size > 0 && len(b.buf) >= b.size {
+		if err := b.flush(); err != nil {
+			b.err = nil
+			return 0, err
+		}
+	}
+	err := b.err
+	b.err = nil
+	return len(p), err
+}
+
+// Flush writes the current batch to the destination.
+func (b *BatchWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.flush()
+}
+
+// flush writes the current batch in a single call. A batch that fails to be
+// written is dropped so that an unreachable destination cannot grow it without bound.
+// The caller must hold b.mu.
+func (b *BatchWriter) flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
+// flushEvery flushes the batch at the given interval until Close is called,
+// keeping a failure for the next Write to report.
+func (b *BatchWriter) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.mu.Lock()
+			if err := b.flush(); err != nil {
+				b.err = err
+			}
+			b.mu.Unlock()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// Close stops the background flusher, writes the remaining batch and closes
+// the destination if it is an io.Closer. Subsequent writes return os.ErrClosed.
+func (b *BatchWriter) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return os.ErrClosed
+	}
+	b.closed = true
+	if b.done != nil {
+		close(b.done)
+	}
+
+	err := b.flush()
+	if c, ok := b.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchWriter(t *testing.T) {
+	rec := new(callRecorder)
+	batch := NewBatchWriter(rec, 256, 0)
+	l, err := NewLogger(&LogFileConfigs{Output: batch, Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	const entries = 100
+	for i := 0; i < entries; i++ {
+		l.Infof("entry %03d", i)
+	}
+	l.Close()
+	if err := batch.Close(); err != nil {
+		t.Fatalf("closing the batch writer: %v", err)
+	}
+
+	if len(rec.calls) == 0 || len(rec.calls) >= entries/5 {
+		t.Errorf("got %d writes for %d entries, want them coalesced", len(rec.calls), entries)
+	}
+	var all strings.Builder
+	for _, call := range rec.calls {
+		if !strings.HasSuffix(call, "\n") {
+			t.Errorf("write %q splits an entry", call)
+		}
+		all.WriteString(call)
+	}
+	if got := strings.Count(all.String(), "INFO entry "); got != entries {
+		t.Errorf("batches hold %d entries, want %d", got, entries)
+	}
+}
+
+// flakyWriter fails while down is set and records what it writes otherwise.
+type flakyWriter struct {
+	mu   sync.Mutex
+	down bool
+	got  strings.Builder
+}
+
+// Write reports an error while the writer is down, and records p otherwise.
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.down {
+		return 0, errors.New("down")
+	}
+	return w.got.Write(p)
+}
+
+func TestBatchWriterRecovers(t *testing.T) {
+	dest := &flakyWriter{down: true}
+	batch := NewBatchWriter(dest, 0, time.Millisecond)
+	defer batch.Close()
+
+	if _, err := batch.Write([]byte("a\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Wait for the background flusher to fail on the unreachable destination.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		batch.mu.Lock()
+		failed := batch.err != nil
+		batch.mu.Unlock()
+		if failed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the background flush did not fail")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	dest.mu.Lock()
+	dest.down = false
+	dest.mu.Unlock()
+
+	// The failure is reported once, and the entry written alongside it is kept.
+	if n, err := batch.Write([]byte("b\n")); n != 2 || err == nil || err.Error() != "down" {
+		t.Errorf("Write = %d, %v; want 2, down", n, err)
+	}
+	if n, err := batch.Write([]byte("c\n")); n != 2 || err != nil {
+		t.Errorf("Write = %d, %v; want 2, nil", n, err)
+	}
+	if err := batch.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+	if got := dest.got.String(); got != "b\nc\n" {
+		t.Errorf("destination got %q, want %q", got, "b\nc\n")
+	}
+}
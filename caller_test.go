@@ -0,0 +1,169 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/azizbek-qodirov/logger"
+)
+
+// The logger skips every stack frame of its own package when resolving the
+// source location, so these tests live outside of it to observe their own lines.
+
+// callerLine returns the line number of its caller.
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// newLogger returns a Logger created with opts that writes to the returned buffer.
+func newLogger(t *testing.T, opts ...logger.Option) (*logger.Logger, *bytes.Buffer) {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	l, err := logger.New(append([]logger.Option{logger.WithWriter(buf)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l, buf
+}
+
+func TestCallSitePerEntry(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.ShortFileName))
+
+	l.INFO.Println("first")
+	first := callerLine() - 1
+	l.INFO.Println("second")
+	second := callerLine() - 1
+
+	want := fmt.Sprintf("caller_test.go:%d first\ncaller_test.go:%d second\n", first, second)
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestErrorfCallSite(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.Loglevel|logger.ShortFileName))
+
+	l.Errorf("failed %d", 1)
+	line := callerLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("ERROR caller_test.go:%d failed 1\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStdFlags(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.StdFlags))
+
+	l.INFO.Println("hello")
+	line := callerLine() - 1
+
+	entry := regexp.MustCompile(fmt.Sprintf(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} INFO caller_test.go:%d hello\n$`, line))
+	if got := buf.String(); !entry.MatchString(got) {
+		t.Errorf("got %q, want a match of %s", got, entry)
+	}
+}
+
+// logFromHelper logs through l so that the function name can be checked.
+func logFromHelper(l *logger.Logger) {
+	l.Info("from helper")
+}
+
+func TestFuncName(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.FuncName))
+
+	logFromHelper(l)
+
+	if got, want := buf.String(), "logFromHelper from helper\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// wrappedInfo is a helper around the Logger that reports its caller's location.
+func wrappedInfo(l *logger.Logger, msg string) {
+	l.WithCallerSkip(1).Info(msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.ShortFileName))
+
+	wrappedInfo(l, "wrapped")
+	line := callerLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("caller_test.go:%d wrapped\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCallerSkipConfig(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.ShortFileName), func(c *logger.LogFileConfigs) { c.CallerSkip = 1 })
+
+	func() { l.Info("wrapped") }()
+	line := callerLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("caller_test.go:%d wrapped\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSetInclude(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.Loglevel))
+
+	l.Info("before")
+	if err := l.SetInclude(logger.Loglevel | logger.ShortFileName); err != nil {
+		t.Fatalf("SetInclude: %v", err)
+	}
+	l.Info("after")
+	line := callerLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("INFO before\nINFO caller_test.go:%d after\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestErrorWithStack(t *testing.T) {
+	l, buf := newLogger(t, logger.WithInclude(logger.Loglevel))
+
+	l.ErrorWithStack(errors.New("boom"), "failed")
+	line := callerLine() - 1
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "ERROR failed error=boom") {
+		t.Errorf("entry %q does not start with the message and error", out)
+	}
+	frame := fmt.Sprintf("logger_test.TestErrorWithStack (%s:%d)", callerFile(), line)
+	if !strings.Contains(out, "\n\t") || !strings.Contains(out, frame) {
+		t.Errorf("stack of %q lacks an indented frame %q", out, frame)
+	}
+}
+
+// callerFile returns the path of its caller's source file.
+func callerFile() string {
+	_, file, _, _ := runtime.Caller(1)
+	return file
+}
+
+func TestErrorWithStackJSON(t *testing.T) {
+	l, buf := newLogger(t, logger.WithJSON(), logger.WithInclude(0))
+
+	l.ErrorWithStack(errors.New("boom"), "failed")
+
+	var m struct {
+		Error string   `json:"error"`
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("entry %q is not valid JSON: %v", buf, err)
+	}
+	if m.Error != "boom" || len(m.Stack) == 0 || !strings.HasPrefix(m.Stack[0], "github.com/azizbek-qodirov/logger_test.TestErrorWithStackJSON ") {
+		t.Errorf("error = %q, stack = %q, want boom and a stack starting at this test", m.Error, m.Stack)
+	}
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"os"
+	"strconv"
+)
+
+// levelColors maps each Level to the ANSI color code used for its label on a terminal.
+var levelColors = map[Level]int{
+	TRACE: 90, // Bright black (gray).
+	DEBUG: 36, // Cyan.
+	INFO:  32, // Green.
+	WARN:  33, // Yellow.
+	ERROR: 31, // Red.
+	FATAL: 35, // Magenta.
+}
+
+// colorReset is the ANSI escape code that restores the terminal's default color.
+const colorReset = "\x1b[0m"
+
+// colorCode returns the ANSI escape code that switches to the color of the given level.
+func colorCode(level Level) string {
+	return "\x1b[" + strconv.Itoa(levelColors[level]) + "m"
+}
+
+// colorize wraps label in the ANSI escape codes of the given level.
+func colorize(level Level, label string) string {
+	return colorCode(level) + label + colorReset
+}
+
+// isTerminal reports whether f is a character device such as a terminal,
+// as opposed to a pipe or a regular file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
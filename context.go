@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+)
+
+// TraceExtractor returns the IDs of the trace and span carried by ctx, or empty
+// strings when ctx carries none. It keeps tracing libraries out of the
+// dependencies of this package; with OpenTelemetry it is typically:
+//
+//	func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return "", ""
+//		}
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	}
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+// TraceContext writes msg at TRACE level with the configured context values
+// and the given key/value pairs as fields.
+func (l *Logger) TraceContext(ctx context.Context, msg string, kv ...any) {
+	l.logContext(ctx, TRACE, msg, kv)
+}
+
+// DebugContext writes msg at DEBUG level with the configured context values
+// and the given key/value pairs as fields.
+func (l *Logger) DebugContext(ctx context.Context, msg string, kv ...any) {
+	l.logContext(ctx, DEBUG, msg, kv)
+}
+
+// InfoContext writes msg at INFO level with the configured context values
+// and the given key/value pairs as fields.
+func (l *Logger) InfoContext(ctx context.Context, msg string, kv ...any) {
+	l.logContext(ctx, INFO, msg, kv)
+}
+
+// WarnContext writes msg at WARN level with the configured context values
+// and the given key/value pairs as fields.
+func (l *Logger) WarnContext(ctx context.Context, msg string, kv ...any) {
+	l.logContext(ctx, WARN, msg, kv)
+}
+
+// ErrorContext writes msg at ERROR level with the configured context values
+// and the given key/value pairs as fields.
+func (l *Logger) ErrorContext(ctx context.Context, msg string, kv ...any) {
+	l.logContext(ctx, ERROR, msg, kv)
+}
+
+// logContext writes msg at the given level with the context values and key/value
+// pairs as fields. The fields are only collected when the level is enabled.
+func (l *Logger) logContext(ctx context.Context, level Level, msg string, kv []any) {
+	if w := l.enabledWriter(level); w != nil {
+		w.write(msg, l.contextFields(ctx, kv))
+	}
+}
+
+// contextFields returns the trace_id and span_id found by the TraceExtractor and
+// a field for every configured context key that has a value in ctx, named after
+// the key, followed by the given key/value pairs.
+func (l *Logger) contextFields(ctx context.Context, kv []any) []field {
+	var fields []field
+	if ctx != nil {
+		if l.tracing != nil {
+			traceID, spanID := l.tracing(ctx)
+			if traceID != "" {
+				fields = append(fields, field{key: "trace_id", value: traceID})
+			}
+			if spanID != "" {
+				fields = append(fields, field{key: "span_id", value: spanID})
+			}
+		}
+		for _, key := range l.ctxKeys {
+			if value := ctx.Value(key); value != nil {
+				fields = append(fields, field{key: fmt.Sprint(key), value: value})
+			}
+		}
+	}
+	return append(fields, toFields(kv)...)
+}
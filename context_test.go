@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+// ctxKey is the type of the context keys used in tests.
+type ctxKey string
+
+func TestInfoContext(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, ContextKeys: []any{ctxKey("request_id")}})
+
+	ctx := context.WithValue(context.Background(), ctxKey("request_id"), "abc")
+	l.InfoContext(ctx, "handled", "status", 200)
+
+	if got, want := buf.String(), "INFO handled request_id=abc status=200\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// spanKey is the context key of the fake span used in tests.
+type spanKey struct{}
+
+// fakeSpan stands in for the span context of a tracing library.
+type fakeSpan struct {
+	traceID, spanID string
+}
+
+func TestTraceExtractor(t *testing.T) {
+	extract := func(ctx context.Context) (string, string) {
+		span, _ := ctx.Value(spanKey{}).(fakeSpan)
+		return span.traceID, span.spanID
+	}
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, TraceExtractor: extract})
+
+	ctx := context.WithValue(context.Background(), spanKey{}, fakeSpan{"4bf92f35", "00f067aa"})
+	l.ErrorContext(ctx, "failed")
+	l.InfoContext(context.Background(), "untraced")
+
+	if got, want := buf.String(), "ERROR failed trace_id=4bf92f35 span_id=00f067aa\nINFO untraced\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// deduper suppresses consecutive identical entries per level and replaces them
+// with a single "last message repeated N times" entry once a different entry
+// arrives or the Logger is closed.
+// It is shared with the loggers derived through With and is safe for concurrent use.
+// last: The most recent entry written at each level.
+type deduper struct {
+	mu   sync.Mutex
+	last [FATAL + 1]dedupState
+}
+
+// dedupState tracks the most recent entry written at one level.
+// key: Message and fields of the entry.
+// repeats: Number of identical entries suppressed since it was written.
+// w: The writer the entry was written through, used to report the repeats.
+type dedupState struct {
+	key     string
+	repeats int
+	w       *levelWriter
+}
+
+// newDeduper returns a deduper, or nil when deduplication is disabled.
+func newDeduper(enabled bool) *deduper {
+	if !enabled {
+		return nil
+	}
+	return &deduper{}
+}
+
+// dedupKey identifies an entry by its message and fields.
+func dedupKey(e entry) string {
+	var b strings.Builder
+	b.WriteString(e.msg)
+	for _, f := range e.fields {
+		b.WriteString("\x00" + f.key + "=" + formatValue(f.value))
+	}
+	return b.String()
+}
+
+// check records that e is about to be written through w.
+// Returns whether e repeats the previous entry at its level and should be
+// suppressed, otherwise the writer and count of suppressed repeats of the
+// previous entry that still have to be reported.
+func (d *deduper) check(w *levelWriter, e entry) (dup bool, prev *levelWriter, repeats int) {
+	if d == nil || w.level < TRACE || w.level > FATAL {
+		return false, nil, 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := &d.last[w.level]
+	key := dedupKey(e)
+	if s.w != nil && s.key == key {
+		s.repeats++
+		return true, nil, 0
+	}
+
+	prev, repeats = s.w, s.repeats
+	*s = dedupState{key: key, w: w}
+	return false, prev, repeats
+}
+
+// flush reports the repeats that are still pending at every level.
+func (d *deduper) flush() {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	pending := d.last
+	for i := range d.last {
+		d.last[i].repeats = 0
+	}
+	d.mu.Unlock()
+
+	for _, s := range pending {
+		if s.repeats > 0 {
+			s.w.emit(s.w.newEntry(repeatedMessage(s.repeats), nil))
+		}
+	}
+}
+
+// repeatedMessage returns the message that reports n suppressed repeats.
+func repeatedMessage(n int) string {
+	return fmt.Sprintf("last message repeated %d times", n)
+}
@@ -0,0 +1,20 @@
+package logger
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDedup(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, Dedup: true})
+
+	for i := 0; i < 5; i++ {
+		l.ERROR.Println("disk full")
+	}
+	l.ERROR.Println("disk ok")
+
+	want := []string{"ERROR disk full", "ERROR last message repeated 4 times", "ERROR disk ok"}
+	if got := lines(buf); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package logger
+
+import "sync/atomic"
+
+// defaultLogger is the Logger used by the package-level logging functions,
+// created on first use unless one is installed with SetDefault.
+var defaultLogger atomic.Pointer[Logger]
+
+// Default returns the package-level Logger used by Info, Errorf and the other
+// package functions. Unless SetDefault has been called, it is created on first
+// use and writes to stdout with the date and time, level and short file name.
+func Default() *Logger {
+	if l := defaultLogger.Load(); l != nil {
+		return l
+	}
+
+	l, err := NewLogger(nil)
+	if err != nil {
+		l = NewNopLogger()
+	}
+	if !defaultLogger.CompareAndSwap(nil, l) {
+		return defaultLogger.Load()
+	}
+	return l
+}
+
+// SetDefault makes l the Logger used by the package-level logging functions.
+// After SetDefault(nil), a new stdout Logger is created on next use.
+func SetDefault(l *Logger) {
+	defaultLogger.Store(l)
+}
+
+// Trace writes msg at TRACE level to the default Logger with the given key/value pairs as fields.
+func Trace(msg string, kv ...any) {
+	Default().log(TRACE, msg, kv)
+}
+
+// Debug writes msg at DEBUG level to the default Logger with the given key/value pairs as fields.
+func Debug(msg string, kv ...any) {
+	Default().log(DEBUG, msg, kv)
+}
+
+// Info writes msg at INFO level to the default Logger with the given key/value pairs as fields.
+func Info(msg string, kv ...any) {
+	Default().log(INFO, msg, kv)
+}
+
+// Warn writes msg at WARN level to the default Logger with the given key/value pairs as fields.
+func Warn(msg string, kv ...any) {
+	Default().log(WARN, msg, kv)
+}
+
+// Warning is an alias of Warn; Warn is the canonical name.
+func Warning(msg string, kv ...any) {
+	Default().log(WARN, msg, kv)
+}
+
+// Error writes msg at ERROR level to the default Logger with the given key/value pairs as fields.
+func Error(msg string, kv ...any) {
+	Default().log(ERROR, msg, kv)
+}
+
+// Fatal writes msg at FATAL level to the default Logger with the given
+// key/value pairs as fields, and then terminates the program with exit code 1.
+func Fatal(msg string, kv ...any) {
+	Default().Fatal(msg, kv...)
+}
+
+// Tracef formats the message like fmt.Sprintf and writes it at TRACE level to the default Logger.
+func Tracef(format string, args ...any) {
+	Default().printf(TRACE, format, args)
+}
+
+// Debugf formats the message like fmt.Sprintf and writes it at DEBUG level to the default Logger.
+func Debugf(format string, args ...any) {
+	Default().printf(DEBUG, format, args)
+}
+
+// Infof formats the message like fmt.Sprintf and writes it at INFO level to the default Logger.
+func Infof(format string, args ...any) {
+	Default().printf(INFO, format, args)
+}
+
+// Warnf formats the message like fmt.Sprintf and writes it at WARN level to the default Logger.
+func Warnf(format string, args ...any) {
+	Default().printf(WARN, format, args)
+}
+
+// Warningf is an alias of Warnf; Warnf is the canonical name.
+func Warningf(format string, args ...any) {
+	Default().printf(WARN, format, args)
+}
+
+// Errorf formats the message like fmt.Sprintf and writes it at ERROR level to the default Logger.
+func Errorf(format string, args ...any) {
+	Default().printf(ERROR, format, args)
+}
+
+// FatalCode writes msg at FATAL level to the default Logger with the given
+// key/value pairs as fields, and then terminates the program with the given exit code.
+func FatalCode(code int, msg string, kv ...any) {
+	Default().FatalCode(code, msg, kv...)
+}
+
+// Fatalf formats the message like fmt.Sprintf, writes it at FATAL level to the
+// default Logger and then terminates the program with exit code 1.
+func Fatalf(format string, args ...any) {
+	Default().Fatalf(format, args...)
+}
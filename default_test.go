@@ -0,0 +1,21 @@
+package logger
+
+import "testing"
+
+func TestSetDefault(t *testing.T) {
+	previous := defaultLogger.Load()
+	t.Cleanup(func() { SetDefault(previous) })
+
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+	SetDefault(l)
+
+	Info("started", "port", 8080)
+	Errorf("failed %d times", 2)
+
+	if got, want := buf.String(), "INFO started port=8080\nERROR failed 2 times\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if Default() != l {
+		t.Error("Default does not return the Logger passed to SetDefault")
+	}
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"time"
+)
+
+// Encoder renders entries in a custom format, such as logfmt or a binary
+// encoding, in place of the built-in text and JSON formats. The returned bytes
+// are written as they are, so line-oriented formats should end with a newline.
+// Encode is called concurrently and must be safe for concurrent use. The
+// returned slice may be written after Encode returns, e.g. with Async, and must
+// not be reused by the Encoder.
+type Encoder interface {
+	Encode(e Entry) ([]byte, error)
+}
+
+// EncoderFunc adapts an ordinary function to the Encoder interface.
+type EncoderFunc func(e Entry) ([]byte, error)
+
+// Encode calls f(e).
+func (f EncoderFunc) Encode(e Entry) ([]byte, error) {
+	return f(e)
+}
+
+// NewTextEncoder returns the built-in text Encoder, rendering the syntax
+// elements in include with the default time layout. It can be wrapped by
+// custom encoders that only alter part of the output.
+func NewTextEncoder(include logSyntax) Encoder {
+	return newBuiltinEncoder(FormatText, include)
+}
+
+// NewJSONEncoder returns the built-in JSON Encoder, rendering the syntax
+// elements in include with the default time layout and member names.
+func NewJSONEncoder(include logSyntax) Encoder {
+	return newBuiltinEncoder(FormatJSON, include)
+}
+
+// newBuiltinEncoder returns a formatter rendering entries in format with the
+// syntax elements in include and default settings otherwise.
+func newBuiltinEncoder(format logFormat, include logSyntax) formatter {
+	f := formatter{
+		format: format,
+		layout: timeLayout,
+		keys:   FieldKeys{}.withDefaults(),
+		pid:    os.Getpid(),
+	}
+	f.setSyntax(include)
+	f.start = time.Now()
+	return f
+}
+
+// Encode renders e in the formatter's text or JSON format, making the built-in
+// formats usable as an Encoder.
+func (f formatter) Encode(e Entry) ([]byte, error) {
+	return f.encode(e.Level, f.importEntry(e))
+}
+
+// importEntry converts e into the entry rendered by the formatter.
+func (f formatter) importEntry(e Entry) entry {
+	internal := entry{
+		time:      e.Time,
+		level:     f.levelName(e.Level),
+		file:      e.File,
+		line:      e.Line,
+		function:  e.Function,
+		goroutine: e.Goroutine,
+		msg:       e.Message,
+	}
+	for _, fld := range e.Fields {
+		internal.fields = append(internal.fields, field{key: fld.Key, value: fld.Value})
+	}
+	return internal
+}
+
+// MarshalJSON renders e as the JSON object the built-in JSON format writes for
+// it, without the trailing newline, so that hooks and custom sinks serialize
+// entries consistently with the log files. Entries captured from a Logger use
+// its FieldKeys, time layout, included elements and MaxLineBytes; other entries
+// are rendered with the defaults of NewJSONEncoder and DateTime and Loglevel included.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	var f formatter
+	if e.formatter != nil {
+		f = *e.formatter
+	} else {
+		f = newBuiltinEncoder(FormatJSON, DateTime|Loglevel)
+	}
+	return bytes.TrimSuffix(f.encodeJSONLine(f.importEntry(e)), []byte("\n")), nil
+}
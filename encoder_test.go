@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCustomEncoder(t *testing.T) {
+	logfmt := EncoderFunc(func(e Entry) ([]byte, error) {
+		b := fmt.Appendf(nil, "level=%s msg=%q", e.Level, e.Message)
+		for _, f := range e.Fields {
+			b = fmt.Appendf(b, " %s=%v", f.Key, f.Value)
+		}
+		return append(b, '\n'), nil
+	})
+	l, buf := newBufferLogger(t, LogFileConfigs{Encoder: logfmt})
+
+	l.Warn("slow request", "ms", 250)
+
+	if got, want := buf.String(), "level=WARN msg=\"slow request\" ms=250\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuiltinEncoders(t *testing.T) {
+	e := Entry{Level: INFO, Message: "hello", Fields: []Field{{"k", "v"}}}
+
+	for _, tt := range []struct {
+		enc  Encoder
+		want string
+	}{
+		{NewTextEncoder(Loglevel), "INFO hello k=v\n"},
+		{NewJSONEncoder(Loglevel), `{"level":"INFO","msg":"hello","k":"v"}` + "\n"},
+	} {
+		b, err := tt.enc.Encode(e)
+		if err != nil || string(b) != tt.want {
+			t.Errorf("Encode = %q, %v, want %q", b, err, tt.want)
+		}
+	}
+}
+
+func TestMarshalJSONMatchesEncoder(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{
+		Format:       FormatJSON,
+		Include:      DateTime | Loglevel | ShortFileName | FuncName | GoroutineID | PID,
+		FieldKeys:    FieldKeys{Message: "message"},
+		MaxLineBytes: 300,
+		Observe:      true,
+	})
+
+	l.Info("short", "k", "v")
+	l.Info(strings.Repeat("long ", 100), "k", "v")
+
+	written := lines(buf)
+	observed := l.Observed()
+	if len(written) != 2 || len(observed) != 2 {
+		t.Fatalf("wrote %d and observed %d entries, want 2", len(written), len(observed))
+	}
+	for i, e := range observed {
+		if e.Goroutine == 0 {
+			t.Errorf("entry %d carries no goroutine ID", i)
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("json.Marshal: %v", err)
+		}
+		if string(b) != written[i] {
+			t.Errorf("json.Marshal(entry %d) = %s, want the encoder output %s", i, b, written[i])
+		}
+	}
+	if !strings.Contains(written[1], "...[truncated ") {
+		t.Errorf("long entry %s was not truncated", written[1])
+	}
+}
@@ -0,0 +1,33 @@
+package logger
+
+// Event types of the Windows Event Log.
+const (
+	eventTypeError       uint16 = 0x0001
+	eventTypeWarning     uint16 = 0x0002
+	eventTypeInformation uint16 = 0x0004
+)
+
+// defaultEventLogLevel is the minimum level of the entries reported to the
+// Windows Event Log when EventLogLevel is not set, keeping routine entries out of it.
+const defaultEventLogLevel = WARN
+
+// eventLogLevel returns the minimum level of the entries reported to the event
+// log for the configured level, defaultEventLogLevel when it is not set.
+func eventLogLevel(level Level) Level {
+	if level == TRACE {
+		return defaultEventLogLevel
+	}
+	return level
+}
+
+// eventType returns the Windows Event Log type entries at level are reported with.
+func eventType(level Level) uint16 {
+	switch {
+	case level >= ERROR:
+		return eventTypeError
+	case level == WARN:
+		return eventTypeWarning
+	default:
+		return eventTypeInformation
+	}
+}
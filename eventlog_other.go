@@ -0,0 +1,13 @@
+//go:build !windows
+
+package logger
+
+import (
+	"errors"
+	"io"
+)
+
+// newEventLogWriters reports that the Windows Event Log is unavailable on this platform.
+func newEventLogWriters(source string, minLevel Level) (map[Level]io.Writer, io.Closer, error) {
+	return nil, nil, errors.New("event log is only supported on Windows")
+}
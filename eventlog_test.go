@@ -0,0 +1,34 @@
+package logger
+
+import "testing"
+
+func TestEventType(t *testing.T) {
+	want := map[Level]uint16{
+		TRACE: eventTypeInformation,
+		DEBUG: eventTypeInformation,
+		INFO:  eventTypeInformation,
+		WARN:  eventTypeWarning,
+		ERROR: eventTypeError,
+		FATAL: eventTypeError,
+	}
+	for level, typ := range want {
+		if got := eventType(level); got != typ {
+			t.Errorf("eventType(%v) = %#x, want %#x", level, got, typ)
+		}
+	}
+}
+
+func TestEventLogLevel(t *testing.T) {
+	tests := []struct {
+		configured, want Level
+	}{
+		{TRACE, WARN},
+		{DEBUG, DEBUG},
+		{ERROR, ERROR},
+	}
+	for _, tt := range tests {
+		if got := eventLogLevel(tt.configured); got != tt.want {
+			t.Errorf("eventLogLevel(%v) = %v, want %v", tt.configured, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+//go:build windows
+
+package logger
+
+import (
+	"io"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+)
+
+// eventID is the ID of the events reported by the Logger.
+const eventID = 1
+
+// eventLog is a handle to an event source of the Windows Event Log.
+type eventLog struct {
+	handle uintptr
+}
+
+// newEventLogWriters registers source with the Windows Event Log and returns a
+// writer for every level at or above minLevel that reports entries with the
+// matching event type, along with the handle that has to be closed once
+// logging is done.
+func newEventLogWriters(source string, minLevel Level) (map[Level]io.Writer, io.Closer, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	handle, _, err := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return nil, nil, err
+	}
+
+	log := &eventLog{handle: handle}
+	writers := make(map[Level]io.Writer)
+	for _, level := range levels {
+		if level >= minLevel {
+			writers[level] = &eventLogWriter{log: log, eventType: eventType(level)}
+		}
+	}
+	return writers, log, nil
+}
+
+// report writes msg to the event log with the given event type.
+func (e *eventLog) report(eventType uint16, msg string) error {
+	// The message is passed as a C string, which cannot contain NUL characters.
+	text, err := syscall.UTF16PtrFromString(strings.ReplaceAll(msg, "\x00", ""))
+	if err != nil {
+		return err
+	}
+	strs := []*uint16{text}
+	ok, _, err := procReportEvent.Call(e.handle, uintptr(eventType), 0, eventID, 0, 1, 0,
+		uintptr(unsafe.Pointer(&strs[0])), 0)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// Close deregisters the event source.
+func (e *eventLog) Close() error {
+	if ok, _, err := procDeregisterEventSource.Call(e.handle); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// eventLogWriter is an io.Writer that reports every entry to the Windows Event Log with a fixed event type.
+// log: The event source the entries are reported to.
+// eventType: Type the entries are reported with.
+type eventLogWriter struct {
+	log       *eventLog
+	eventType uint16
+}
+
+// Write reports p to the event log with the writer's event type.
+// Returns the number of bytes of p consumed and any error from the event log.
+func (w *eventLogWriter) Write(p []byte) (int, error) {
+	if err := w.log.report(w.eventType, strings.TrimRight(string(p), "\r\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,788 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// timeLayout is the default layout used to render timestamps.
+const timeLayout = "2006-01-02 15:04:05"
+
+// formatter holds the settings that control how entries are rendered.
+// syntax: Bitmask that determines which parts of the entry are included.
+// format: Whether entries are rendered as text or JSON.
+// layout: Configured timestamp layout, before fractional seconds are added.
+// timeFormat: Layout used to render timestamps.
+// utc: Whether timestamps are converted to UTC before rendering.
+// levelNames: Labels overriding the default label of individual levels.
+// prefix: Static token written between the timestamp and the level, empty for none.
+// start: Moment the Logger was created, the origin of ElapsedTime timestamps.
+// epoch: Whether JSON entries carry the time as nanoseconds since the Unix epoch.
+// hostname: Name of the host, resolved once when the Logger is created.
+// pid: ID of the process.
+// keys: Names of the standard members of JSON entries.
+// encoder: Custom Encoder replacing the text and JSON formats, nil for the built-in ones.
+// maxLine: Length in bytes above which built-in entries are truncated, 0 for no limit.
+// separator: Written after every component of the text prefix, a space when empty.
+// levelFirst: Whether the level opens the text prefix, ahead of the timestamp.
+type formatter struct {
+	syntax     logSyntax
+	format     logFormat
+	layout     string
+	timeFormat string
+	utc        bool
+	levelNames map[Level]string
+	prefix     string
+	start      time.Time
+	epoch      bool
+	hostname   string
+	pid        int
+	keys       FieldKeys
+	encoder    Encoder
+	maxLine    int
+	separator  string
+	levelFirst bool
+}
+
+// setSyntax replaces the syntax bitmask and derives the settings that depend on
+// it: the fractional seconds of the time layout and the hostname.
+func (f *formatter) setSyntax(syntax logSyntax) {
+	f.syntax = syntax
+	f.timeFormat = fractionLayout(f.layout, syntax)
+	if syntax&Hostname != 0 && f.hostname == "" {
+		f.hostname, _ = os.Hostname()
+	}
+}
+
+// formatTime renders t with the configured layout, in UTC when requested,
+// or as the time elapsed since the Logger was created when ElapsedTime is set.
+func (f formatter) formatTime(t time.Time) string {
+	if f.syntax&ElapsedTime != 0 {
+		return formatElapsed(t.Sub(f.start))
+	}
+	if f.utc {
+		t = t.UTC()
+	}
+	return t.Format(f.timeFormat)
+}
+
+// levelName returns the label rendered for level: its entry in levelNames if
+// any, otherwise the default label, abbreviated to one letter with ShortLevel.
+func (f formatter) levelName(level Level) string {
+	if name, ok := f.levelNames[level]; ok {
+		return name
+	}
+	if name := levelNames[level]; f.syntax&ShortLevel != 0 && name != "" {
+		return name[:1]
+	}
+	return levelNames[level]
+}
+
+// fractionLayout extends layout with fractional seconds when syntax contains
+// Milliseconds or Microseconds. The fraction is inserted after the seconds
+// element, so layouts such as time.RFC3339 keep their zone suffix; layouts that
+// already contain fractional seconds or no seconds are returned unchanged.
+func fractionLayout(layout string, syntax logSyntax) string {
+	fraction := ""
+	switch {
+	case syntax&Microseconds != 0:
+		fraction = ".000000"
+	case syntax&Milliseconds != 0:
+		fraction = ".000"
+	default:
+		return layout
+	}
+
+	i := strings.LastIndex(layout, "05")
+	if i < 0 || strings.HasPrefix(layout[i+2:], ".0") || strings.HasPrefix(layout[i+2:], ".9") ||
+		strings.HasPrefix(layout[i+2:], ",0") || strings.HasPrefix(layout[i+2:], ",9") {
+		return layout
+	}
+	return layout[:i+2] + fraction + layout[i+2:]
+}
+
+// formatElapsed renders d as +hh:mm:ss.mmm, clamping negative durations to zero.
+func formatElapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("+%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// entry holds the parts of a single log entry before it is encoded.
+// time: Moment the entry was written.
+// level: The log level string (e.g., "INFO").
+// file, line: Source location of the log call, empty when not requested.
+// function: Short name of the calling function, empty when not requested.
+// goroutine: ID of the goroutine that wrote the entry, 0 when not requested.
+// msg: The message without its trailing newline.
+// fields: Key/value pairs attached to the entry.
+type entry struct {
+	time      time.Time
+	level     string
+	file      string
+	line      int
+	function  string
+	goroutine uint64
+	msg       string
+	fields    []field
+}
+
+// field is a single key/value pair attached to an entry.
+type field struct {
+	key   string
+	value any
+}
+
+// badKey is the key used for a value that has no key of its own.
+const badKey = "!BADKEY"
+
+// toFields converts alternating key/value arguments into fields.
+// Keys are rendered with fmt.Sprint; a trailing value without a key is stored under badKey.
+func toFields(kv []any) []field {
+	fields := make([]field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields = append(fields, field{key: badKey, value: kv[i]})
+			break
+		}
+		fields = append(fields, field{key: fmt.Sprint(kv[i]), value: kv[i+1]})
+	}
+	return fields
+}
+
+// levelWriter is an io.Writer that turns every message it receives from a
+// *log.Logger into a fully formatted entry and passes it on to the underlying writers.
+// out: The destination writer for plain entries (stdout, file or both).
+// colorOut: The destination writer for colorized entries, nil when color is disabled.
+// systemOut: The system log or Windows Event Log of the level, nil when not configured.
+// formatter: Settings that control how entries are rendered.
+// systemFormatter: Settings for entries written to systemOut, which timestamps them itself.
+// level: The level of the entries written through this writer.
+// fields: Key/value pairs appended to every entry.
+// now: Clock used to timestamp entries.
+// hooks: Hooks called for every written entry.
+// sampler: Decides which entries are dropped when sampling is enabled.
+// dedup: Suppresses consecutive identical entries when deduplication is enabled.
+// onError: Receives the errors reported by the underlying writers.
+// syncFiles: Log files synced to disk after every entry, empty unless the level is at or above SyncLevel.
+// callerSkip: Additional stack frames skipped when reporting the source location.
+// noLocation: Omits the source location, for entries logged on behalf of callers outside the program's code.
+// redactor: Masks sensitive field values and message contents, nil when nothing is redacted.
+// observer: Captures written entries when Observe is set.
+// limiter: Drops entries exceeding the rate limit, nil when entries are not rate limited.
+// async: Queue handing entries over to a background writer, nil when entries are written synchronously.
+// filter: Predicate deciding whether an entry is written, nil to write every entry.
+// values: Formatters rendering field values by their type.
+type levelWriter struct {
+	out             io.Writer
+	colorOut        io.Writer
+	systemOut       io.Writer
+	formatter       formatter
+	systemFormatter formatter
+	level           Level
+	fields          []field
+	now             func() time.Time
+	hooks           *hooks
+	sampler         *sampler
+	dedup           *deduper
+	onError         *errorHandler
+	syncFiles       []*rotatingFile
+	callerSkip      int
+	noLocation      bool
+	redactor        *redactor
+	observer        *observer
+	limiter         *rateLimiter
+	async           *asyncQueue
+	filter          func(Entry) bool
+	values          valueFormatters
+}
+
+// newLevelWriter returns a levelWriter for the given level that uses the Logger's
+// destinations, including the level's own file, the sinks capturing it and
+// other level-specific destinations, formatting settings, fields and clock.
+func (l *Logger) newLevelWriter(level Level) *levelWriter {
+	outs := []io.Writer{l.out}
+	var files []*rotatingFile
+	if file, ok := l.levelFiles[level]; ok {
+		files = append(files, file)
+	} else if l.file != nil {
+		files = append(files, l.file)
+	}
+	files = append(files, l.sinkFiles[level]...)
+	for _, file := range files {
+		outs = append(outs, file)
+	}
+	for _, w := range l.leveled {
+		outs = append(outs, levelSink{w: w, level: level})
+	}
+	if l.tail != nil {
+		outs = append(outs, l.tail)
+	}
+
+	// Entries at or above SyncLevel are committed to disk as soon as they are written.
+	var syncFiles []*rotatingFile
+	if l.syncLevel > TRACE && level >= l.syncLevel {
+		syncFiles = files
+	}
+
+	// The system log and the event log record the time of every entry themselves.
+	systemFormatter := l.formatter
+	systemFormatter.setSyntax(systemFormatter.syntax &^ (DateTime | ElapsedTime))
+
+	return &levelWriter{
+		out:             io.MultiWriter(outs...),
+		colorOut:        l.colorOut,
+		systemOut:       l.levelOuts[level],
+		formatter:       l.formatter,
+		systemFormatter: systemFormatter,
+		level:           level,
+		fields:          l.fields,
+		now:             l.now,
+		hooks:           l.hooks,
+		sampler:         l.sampler,
+		dedup:           l.dedup,
+		onError:         l.onError,
+		syncFiles:       syncFiles,
+		callerSkip:      l.callerSkip,
+		redactor:        l.redactor,
+		observer:        l.observer,
+		limiter:         l.limiter,
+		async:           l.async,
+		filter:          l.filter,
+		values:          l.values,
+	}
+}
+
+// Write builds an entry for the current moment and call site from the message
+// written by a *log.Logger and writes it in a single call to each underlying writer.
+// Returns the number of bytes of p consumed and any error from the underlying writers.
+func (w *levelWriter) Write(p []byte) (int, error) {
+	if err := w.write(string(p), nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// write builds an entry for msg at the current moment and call site, carrying
+// the writer's fields followed by extra, and writes it to the underlying writers.
+// Trailing newlines are stripped from msg so that every entry ends with exactly
+// one newline, whether it was logged with Print, Println or a message ending in "\n".
+// Returns the first error reported by an underlying writer.
+func (w *levelWriter) write(msg string, extra []field) error {
+	msg = strings.TrimRight(msg, "\r\n")
+
+	fields := w.fields
+	if len(extra) > 0 {
+		fields = append(w.fields[:len(w.fields):len(w.fields)], extra...)
+	}
+	e := w.newEntry(w.redactor.message(msg), w.redactor.fields(w.values.fields(fields)))
+
+	// Filtered entries count towards neither sampling nor the rate limit.
+	if w.filter != nil && !w.filter(exportEntry(w.level, e, &w.formatter)) {
+		return nil
+	}
+	if !w.sampler.keep(w.level) {
+		return nil
+	}
+	// Report the entries dropped by the rate limit before the first one let through.
+	if w.limiter != nil {
+		ok, dropped := w.limiter.allow(w.level, e.time)
+		if !ok {
+			return nil
+		}
+		if dropped > 0 {
+			if err := w.emit(w.newEntry(droppedMessage(dropped), nil)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Report the repeats of the previous entry before the first entry that differs from it.
+	dup, prev, repeats := w.dedup.check(w, e)
+	if dup {
+		return nil
+	}
+	if repeats > 0 {
+		if err := prev.emit(prev.newEntry(repeatedMessage(repeats), nil)); err != nil {
+			return err
+		}
+	}
+	return w.emit(e)
+}
+
+// newEntry builds an entry for msg and fields at the current moment and call site.
+func (w *levelWriter) newEntry(msg string, fields []field) entry {
+	e := entry{
+		time:   w.now(),
+		level:  w.formatter.levelName(w.level),
+		msg:    msg,
+		fields: fields,
+	}
+
+	// Resolve the source location only when it is going to be rendered.
+	if syntax := w.formatter.syntax; !w.noLocation && syntax&(ShortFileName|LongFileName|FuncName) != 0 {
+		if frame, ok := caller(w.callerSkip); ok {
+			if syntax&(ShortFileName|LongFileName) != 0 {
+				e.file, e.line = frame.File, frame.Line
+				if syntax&ShortFileName != 0 {
+					e.file = filepath.Base(e.file)
+				}
+			}
+			if syntax&FuncName != 0 {
+				e.function = shortFuncName(frame.Function)
+			}
+		}
+	}
+	if w.formatter.syntax&GoroutineID != 0 {
+		e.goroutine = goroutineID()
+	}
+	return e
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from the header
+// of its stack trace ("goroutine 42 [running]:"), or 0 if it cannot be parsed.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// emit encodes e and writes it to the underlying writers, then records it for
+// Observed and fires the hooks.
+// Entries at or above SyncLevel are synced to disk before emit returns.
+// With Async, the encoded entry is queued instead and written, synced and
+// reported to the ErrorHandler by the background writer.
+// A failed write is also passed to the ErrorHandler.
+// Returns the error of a custom Encoder or the first error reported by an
+// underlying writer.
+func (w *levelWriter) emit(e entry) error {
+	// Colorized output gets its own rendering so escape codes never reach the other writers.
+	if w.colorOut != nil {
+		b, err := w.formatter.encodeColored(w.level, e)
+		if err == nil {
+			if w.async != nil {
+				w.async.enqueue(w.level, w.colorOut, b, nil)
+			} else {
+				err = writeEntry(w.colorOut, b, nil)
+			}
+		}
+		if err != nil {
+			w.onError.report(err)
+			return err
+		}
+	}
+
+	b, err := w.formatter.encode(w.level, e)
+	if err == nil {
+		if w.async != nil {
+			w.async.enqueue(w.level, w.out, b, w.syncFiles)
+		} else {
+			err = writeEntry(w.out, b, w.syncFiles)
+		}
+	}
+	if err == nil && w.systemOut != nil {
+		err = w.emitSystem(e)
+	}
+	w.onError.report(err)
+	w.observer.record(w.level, e, &w.formatter)
+	w.hooks.fire(w.level, e)
+	return err
+}
+
+// emitSystem encodes e without the time and writes it to the system log or the event log.
+// Returns the error of a custom Encoder or the error reported by the writer.
+func (w *levelWriter) emitSystem(e entry) error {
+	b, err := w.systemFormatter.encode(w.level, e)
+	if err != nil {
+		return err
+	}
+	if w.async != nil {
+		w.async.enqueue(w.level, w.systemOut, b, nil)
+		return nil
+	}
+	return writeEntry(w.systemOut, b, nil)
+}
+
+// raw writes b unchanged to the underlying writers, bypassing the formatting,
+// sampling, hooks and every other step entries go through.
+// Returns the first error reported by an underlying writer.
+func (w *levelWriter) raw(b []byte) error {
+	if w.async != nil {
+		// The caller may reuse b once Raw returns.
+		b = bytes.Clone(b)
+		if w.colorOut != nil {
+			w.async.enqueue(w.level, w.colorOut, b, nil)
+		}
+		w.async.enqueue(w.level, w.out, b, w.syncFiles)
+		if w.systemOut != nil {
+			w.async.enqueue(w.level, w.systemOut, b, nil)
+		}
+		return nil
+	}
+
+	if w.colorOut != nil {
+		if err := writeEntry(w.colorOut, b, nil); err != nil {
+			w.onError.report(err)
+			return err
+		}
+	}
+	err := writeEntry(w.out, b, w.syncFiles)
+	if err == nil && w.systemOut != nil {
+		err = writeEntry(w.systemOut, b, nil)
+	}
+	w.onError.report(err)
+	return err
+}
+
+// writeEntry writes the encoded entry b to out and then syncs syncFiles to disk.
+// Returns the first error encountered.
+func writeEntry(out io.Writer, b []byte, syncFiles []*rotatingFile) error {
+	_, err := out.Write(b)
+	for _, file := range syncFiles {
+		if err != nil {
+			break
+		}
+		err = file.Sync()
+	}
+	return err
+}
+
+// encode renders the entry, written at level, with the custom Encoder if one
+// is configured and in the configured built-in format otherwise.
+// Returns the error reported by the custom Encoder.
+func (f *formatter) encode(level Level, e entry) ([]byte, error) {
+	if f.encoder != nil {
+		return f.encoder.Encode(exportEntry(level, e, f))
+	}
+	if f.format == FormatJSON {
+		return f.encodeJSONLine(e), nil
+	}
+	b := f.encodeText(e)
+	if f.maxLine > 0 && len(b) > f.maxLine {
+		b = append([]byte(truncate(string(b[:len(b)-1]), f.maxLine)), '\n')
+	}
+	return b, nil
+}
+
+// encodeColored renders the entry like encode for a terminal, with the level
+// label in the ANSI color of level. In text mode MaxLineBytes applies to the
+// entry without the escape codes, so the terminal keeps as much of it as the
+// other destinations, and a label that is cut off still ends with the reset code.
+// Returns the error reported by the custom Encoder.
+func (f *formatter) encodeColored(level Level, e entry) ([]byte, error) {
+	colored := e
+	colored.level = colorize(level, e.level)
+	if f.encoder != nil || f.format == FormatJSON || f.syntax&Loglevel == 0 {
+		return f.encode(level, colored)
+	}
+
+	// Truncate the plain rendering, then color what is left of the label.
+	line := f.encodeText(e)
+	line = line[:len(line)-1]
+	cut := len(line)
+	if f.maxLine > 0 && len(line) > f.maxLine {
+		cut = runeCut(string(line), f.maxLine)
+	}
+	start := strings.Index(f.generatePrefix(colored), colorCode(level))
+	end := min(start+len(e.level), cut)
+
+	b := make([]byte, 0, len(line)+len(colored.level)-len(e.level)+1)
+	if start >= 0 && start < end {
+		b = append(b, line[:start]...)
+		b = append(b, colorCode(level)...)
+		b = append(b, line[start:end]...)
+		b = append(b, colorReset...)
+		b = append(b, line[end:cut]...)
+	} else {
+		b = append(b, line[:cut]...)
+	}
+	if cut < len(line) {
+		b = append(b, truncatedMarker(len(line)-cut)...)
+	}
+	return append(b, '\n'), nil
+}
+
+// encodeJSONLine renders the entry as a JSON object followed by a newline,
+// shortening the message when the line would exceed maxLine.
+func (f formatter) encodeJSONLine(e entry) []byte {
+	b := f.encodeJSON(e)
+	if f.maxLine <= 0 || len(b) <= f.maxLine {
+		return b
+	}
+
+	// Only the message is shortened so that the object stays valid JSON.
+	// The excess is measured on the escaped message, so characters
+	// are kept while their escaped form fits in the remaining budget.
+	budget := len(appendJSONValue(nil, e.msg)) - (len(b) - f.maxLine)
+	keep := 0
+	for keep < len(e.msg) {
+		r, size := utf8.DecodeRuneInString(e.msg[keep:])
+		budget -= len(appendJSONValue(nil, string(r))) - 2
+		if budget < 2 {
+			break
+		}
+		keep += size
+	}
+	e.msg = truncate(e.msg, keep)
+	return f.encodeJSON(e)
+}
+
+// truncate shortens s to at most n bytes, without splitting a UTF-8 sequence,
+// and appends a marker with the number of bytes removed. s is returned
+// unchanged when it is not longer than n.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	n = runeCut(s, n)
+	return s[:n] + truncatedMarker(len(s)-n)
+}
+
+// runeCut returns the largest index of s not above n that does not split a
+// UTF-8 sequence. n must be less than len(s).
+func runeCut(s string, n int) int {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+// truncatedMarker returns the marker appended to an entry shortened by n bytes.
+func truncatedMarker(n int) string {
+	return "...[truncated " + strconv.Itoa(n) + " bytes]"
+}
+
+// encodeText renders the entry as its text prefix followed by the message,
+// its fields as key=value pairs and a newline. A captured stack trace is
+// rendered after them as one indented line per frame.
+func (f formatter) encodeText(e entry) []byte {
+	line := f.generatePrefix(e) + e.msg
+	var stack stackTrace
+	for _, fld := range e.fields {
+		if trace, ok := fld.value.(stackTrace); ok {
+			stack = trace
+			continue
+		}
+		line += " " + fld.key + "=" + formatValue(fld.value)
+	}
+	for _, frame := range stack {
+		line += "\n\t" + frame
+	}
+	return []byte(line + "\n")
+}
+
+// formatValue renders a field value for text output, quoting it when it is
+// empty or contains spaces, quotes or '=' so the key=value pairs stay unambiguous.
+func formatValue(value any) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// generatePrefix generates a log prefix for the entry based on the formatter's syntax.
+// e: The entry whose time, level and source location are rendered.
+// Returns the formatted log prefix string.
+func (f formatter) generatePrefix(e entry) string {
+	prefix := ""
+	sep := f.separator
+	if sep == "" {
+		sep = " "
+	}
+	level := f.syntax&Loglevel != 0
+
+	// Include the log level first if requested, so it can be read without parsing the timestamp.
+	if level && f.levelFirst {
+		prefix += e.level + sep
+	}
+
+	// Include date and time, or the elapsed time, if specified.
+	if f.syntax&(DateTime|ElapsedTime) != 0 {
+		prefix += f.formatTime(e.time) + sep
+	}
+
+	// Include the static prefix if configured.
+	if f.prefix != "" {
+		prefix += f.prefix + sep
+	}
+
+	// Include the host name and process ID if specified, as in host[pid].
+	if f.syntax&Hostname != 0 {
+		prefix += f.hostname
+		if f.syntax&PID == 0 {
+			prefix += sep
+		}
+	}
+	if f.syntax&PID != 0 {
+		prefix += "[" + strconv.Itoa(f.pid) + "]" + sep
+	}
+
+	// Include the log level if specified.
+	if level && !f.levelFirst {
+		prefix += e.level + sep
+	}
+
+	// Include file name and line number if specified.
+	if e.file != "" {
+		prefix += e.file + ":" + strconv.Itoa(e.line) + sep
+	}
+
+	// Include the calling function if specified.
+	if e.function != "" {
+		prefix += e.function + sep
+	}
+
+	// Include the goroutine ID if specified.
+	if e.goroutine != 0 {
+		prefix += "g" + strconv.FormatUint(e.goroutine, 10) + sep
+	}
+
+	return prefix
+}
+
+// encodeJSON renders the entry as a single-line JSON object terminated by a newline.
+// The time, hostname, pid, level, file, line, func and goroutine members are
+// present only when enabled in syntax and prefix only when configured; msg is
+// always present and is followed by the entry's fields. The standard members
+// are named after keys.
+func (f formatter) encodeJSON(e entry) []byte {
+	buf := []byte{'{'}
+
+	if f.syntax&(DateTime|ElapsedTime) != 0 {
+		if f.epoch {
+			buf = appendJSONMember(buf, f.keys.Time, e.time.UnixNano())
+		} else {
+			buf = appendJSONMember(buf, f.keys.Time, f.formatTime(e.time))
+		}
+	}
+	if f.prefix != "" {
+		buf = appendJSONMember(buf, "prefix", f.prefix)
+	}
+	if f.syntax&Hostname != 0 {
+		buf = appendJSONMember(buf, "hostname", f.hostname)
+	}
+	if f.syntax&PID != 0 {
+		buf = appendJSONMember(buf, "pid", f.pid)
+	}
+	if f.syntax&Loglevel != 0 {
+		buf = appendJSONMember(buf, f.keys.Level, e.level)
+	}
+	if e.file != "" {
+		buf = appendJSONMember(buf, f.keys.File, e.file)
+		buf = appendJSONMember(buf, f.keys.Line, e.line)
+	}
+	if e.function != "" {
+		buf = appendJSONMember(buf, f.keys.Func, e.function)
+	}
+	if e.goroutine != 0 {
+		buf = appendJSONMember(buf, "goroutine", e.goroutine)
+	}
+	buf = appendJSONMember(buf, f.keys.Message, e.msg)
+	for _, fld := range e.fields {
+		buf = appendJSONMember(buf, fld.key, fld.value)
+	}
+
+	return append(buf, '}', '\n')
+}
+
+// appendJSONMember appends a "key":value member to an object being built in buf,
+// adding a separating comma when it is not the first member.
+// Values that cannot be marshaled are written as their fmt representation.
+func appendJSONMember(buf []byte, key string, value any) []byte {
+	if len(buf) > 1 {
+		buf = append(buf, ',')
+	}
+	buf = appendJSONValue(buf, key)
+	buf = append(buf, ':')
+	return appendJSONValue(buf, value)
+}
+
+// appendJSONValue appends the JSON encoding of value to buf without escaping HTML characters.
+// Errors are encoded as their message.
+func appendJSONValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case error:
+		value = v.Error()
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		b.Reset()
+		enc.Encode(fmt.Sprint(value))
+	}
+	return append(buf, bytes.TrimSuffix(b.Bytes(), []byte("\n"))...)
+}
+
+// pkgPath is the import path of this package, used to recognise its own stack frames.
+var pkgPath = reflect.TypeOf(levelWriter{}).PkgPath()
+
+// caller locates the code that issued the log call.
+// It walks up the stack, skipping frames that belong to this package or to the
+// standard log package, and returns the first frame outside them.
+// Because the skip is computed from the stack rather than fixed, helper methods
+// such as Errorf report the line where they were called, just like l.ERROR.Println.
+// skip additional frames above that one are skipped for callers that wrap the Logger.
+// Returns false if no such frame could be found.
+func caller(skip int) (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32+max(skip, 0))
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	found := false
+	for {
+		frame, more := frames.Next()
+		if found || !isLoggerFrame(frame.Function) {
+			found = true
+			if skip <= 0 {
+				return frame, true
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// shortFuncName strips the package path from a fully qualified function name
+// (github.com/user/app/server.(*Server).Serve -> (*Server).Serve).
+func shortFuncName(function string) string {
+	if i := strings.LastIndexByte(function, '/'); i >= 0 {
+		function = function[i+1:]
+	}
+	if i := strings.IndexByte(function, '.'); i >= 0 {
+		function = function[i+1:]
+	}
+	return function
+}
+
+// isLoggerFrame reports whether the fully qualified function name belongs to
+// this package or to the standard log package.
+func isLoggerFrame(function string) bool {
+	return strings.HasPrefix(function, "log.") || strings.HasPrefix(function, pkgPath+".")
+}
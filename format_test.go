@@ -0,0 +1,332 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeJSON unmarshals a single JSON entry.
+func decodeJSON(t *testing.T, line string) map[string]any {
+	t.Helper()
+
+	var m map[string]any
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		t.Fatalf("entry %q is not valid JSON: %v", line, err)
+	}
+	return m
+}
+
+func TestJSONFormat(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Format: FormatJSON, Include: DateTime | Loglevel | ShortFileName})
+
+	msg := "quote \" backslash \\ tab \t and <html>\nsecond line"
+	l.ERROR.Println(msg)
+
+	m := decodeJSON(t, buf.String())
+	if m["level"] != "ERROR" {
+		t.Errorf("level = %v, want ERROR", m["level"])
+	}
+	if m["msg"] != msg {
+		t.Errorf("msg = %q, want %q", m["msg"], msg)
+	}
+	for _, key := range []string{"time", "file", "line"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("member %q missing from %s", key, buf)
+		}
+	}
+}
+
+func TestJSONFormatHonorsInclude(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Format: FormatJSON, Include: Loglevel})
+
+	l.INFO.Println("hello")
+
+	if got, want := buf.String(), `{"level":"INFO","msg":"hello"}`+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime, TimeFormat: time.RFC3339Nano})
+	now := time.Date(2024, 6, 1, 12, 0, 0, 123456789, time.UTC)
+	l.setClock(func() time.Time { return now })
+
+	l.INFO.Println("hello")
+
+	stamp, msg, _ := strings.Cut(buf.String(), " ")
+	got, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		t.Fatalf("timestamp %q does not parse: %v", stamp, err)
+	}
+	if !got.Equal(now) {
+		t.Errorf("timestamp = %v, want %v", got, now)
+	}
+	if msg != "hello\n" {
+		t.Errorf("message = %q, want %q", msg, "hello\n")
+	}
+}
+
+func TestUTC(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime, TimeFormat: time.RFC3339, UTC: true})
+	now := time.Date(2024, 6, 1, 17, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60))
+	l.setClock(func() time.Time { return now })
+
+	l.INFO.Println("hello")
+
+	if got, want := buf.String(), "2024-06-01T12:00:00Z hello\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLevelNames(t *testing.T) {
+	names := map[Level]string{WARN: "warning"}
+	for _, format := range []logFormat{FormatText, FormatJSON} {
+		l, buf := newBufferLogger(t, LogFileConfigs{Format: format, Include: Loglevel, LevelNames: names})
+
+		l.WARN.Println("low disk")
+		l.ERROR.Println("full disk")
+
+		want := "warning low disk\nERROR full disk\n"
+		if format == FormatJSON {
+			want = `{"level":"warning","msg":"low disk"}` + "\n" + `{"level":"ERROR","msg":"full disk"}` + "\n"
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("format %d: got %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime | Loglevel, Prefix: "[auth]"})
+	l.setClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local) })
+
+	l.INFO.Println("login")
+	l.ERROR.Println("denied")
+
+	want := "2024-06-01 12:00:00 [auth] INFO login\n2024-06-01 12:00:00 [auth] ERROR denied\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestElapsedTime(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: ElapsedTime})
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	l.setClock(func() time.Time { return now })
+
+	now = now.Add(1234 * time.Millisecond)
+	l.INFO.Println("first")
+	now = now.Add(time.Hour + 2*time.Second)
+	l.INFO.Println("second")
+
+	if got, want := buf.String(), "+00:00:01.234 first\n+01:00:03.234 second\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSingleNewline(t *testing.T) {
+	for _, format := range []logFormat{FormatText, FormatJSON} {
+		l, buf := newBufferLogger(t, LogFileConfigs{Format: format})
+
+		l.INFO.Print("no newline")
+		l.INFO.Print("newline\n")
+		l.INFO.Printf("printf\n\n")
+		l.Info("crlf\r\n")
+
+		got := buf.String()
+		if strings.Count(got, "\n") != 4 || strings.Contains(got, "\n\n") || strings.Contains(got, "\r") {
+			t.Errorf("format %d: got %q, want four entries ending in a single newline", format, got)
+		}
+	}
+}
+
+func TestFractionalSeconds(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 123456789, time.UTC)
+	tests := []struct {
+		include logSyntax
+		layout  string
+		want    string
+	}{
+		{DateTime | Milliseconds, "", "2024-06-01 12:00:00.123 hello\n"},
+		{DateTime | Microseconds, "", "2024-06-01 12:00:00.123456 hello\n"},
+		{DateTime | Milliseconds | Microseconds, "", "2024-06-01 12:00:00.123456 hello\n"},
+		{DateTime | Milliseconds, time.RFC3339, "2024-06-01T12:00:00.123Z hello\n"},
+	}
+	for _, tt := range tests {
+		l, buf := newBufferLogger(t, LogFileConfigs{Include: tt.include, TimeFormat: tt.layout, UTC: true})
+		l.setClock(func() time.Time { return now })
+
+		l.INFO.Println("hello")
+
+		if got := buf.String(); got != tt.want {
+			t.Errorf("Include %b, TimeFormat %q: got %q, want %q", tt.include, tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestShortLevel(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel | ShortLevel, LevelNames: map[Level]string{WARN: "warning"}})
+
+	l.ERROR.Println("failed")
+	l.WARN.Println("slow")
+
+	// LevelNames take precedence over the abbreviation.
+	if got, want := buf.String(), "E failed\nwarning slow\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEpochTime(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Format: FormatJSON, Include: DateTime, EpochTime: true})
+
+	before := time.Now().UnixNano()
+	l.INFO.Println("hello")
+	after := time.Now().UnixNano()
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("entry %q is not valid JSON: %v", buf, err)
+	}
+	var nanos int64
+	if err := json.Unmarshal(m["time"], &nanos); err != nil {
+		t.Fatalf("time %s is not an integer: %v", m["time"], err)
+	}
+	if nanos < before || nanos > after {
+		t.Errorf("time = %d, want between %d and %d", nanos, before, after)
+	}
+}
+
+func TestGoroutineID(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: GoroutineID})
+
+	l.INFO.Println("main")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.INFO.Println("other")
+	}()
+	<-done
+
+	got := lines(buf)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries %q, want 2", len(got), got)
+	}
+	first, _, _ := strings.Cut(got[0], " ")
+	second, _, _ := strings.Cut(got[1], " ")
+	if !strings.HasPrefix(first, "g") || !strings.HasPrefix(second, "g") || first == second {
+		t.Errorf("goroutine tokens %q and %q, want two distinct g<ID> tokens", first, second)
+	}
+}
+
+func TestHostnameAndPID(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("hostname unavailable: %v", err)
+	}
+
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Hostname | PID})
+	l.INFO.Println("text")
+	if got, want := buf.String(), fmt.Sprintf("%s[%d] text\n", hostname, os.Getpid()); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	l, buf = newBufferLogger(t, LogFileConfigs{Format: FormatJSON, Include: Hostname | PID})
+	l.INFO.Println("json")
+	m := decodeJSON(t, buf.String())
+	if m["hostname"] != hostname || m["pid"] != float64(os.Getpid()) {
+		t.Errorf("hostname = %v, pid = %v, want %q and %d", m["hostname"], m["pid"], hostname, os.Getpid())
+	}
+}
+
+func TestFieldKeys(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{
+		Format:    FormatJSON,
+		Include:   DateTime | Loglevel | ShortFileName,
+		FieldKeys: FieldKeys{Time: "ts", Level: "severity", Message: "message", File: "source", Line: "lineno"},
+	})
+
+	l.ERROR.Println("failed")
+
+	m := decodeJSON(t, buf.String())
+	for _, key := range []string{"ts", "severity", "message", "source", "lineno"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("member %q missing from %s", key, buf)
+		}
+	}
+	for _, key := range []string{"time", "level", "msg", "file", "line"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("default member %q present in %s", key, buf)
+		}
+	}
+	if m["severity"] != "ERROR" || m["message"] != "failed" {
+		t.Errorf("severity = %v, message = %v, want ERROR and failed", m["severity"], m["message"])
+	}
+}
+
+func TestMaxLineBytes(t *testing.T) {
+	msg := strings.Repeat("x", 100)
+
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, MaxLineBytes: 30})
+	l.INFO.Println(msg)
+	if got, want := buf.String(), "INFO "+strings.Repeat("x", 25)+"...[truncated 75 bytes]\n"; got != want {
+		t.Errorf("text: got %q, want %q", got, want)
+	}
+
+	l, buf = newBufferLogger(t, LogFileConfigs{Format: FormatJSON, Include: Loglevel, MaxLineBytes: 60})
+	l.INFO.Println(msg)
+	got := buf.String()
+	if !strings.HasSuffix(got, "\n") || strings.Count(got, "\n") != 1 {
+		t.Errorf("json: %q does not end with a single newline", got)
+	}
+	m := decodeJSON(t, got)
+	short, _ := m["msg"].(string)
+	if !strings.HasPrefix(short, "xxx") || !strings.Contains(short, "...[truncated ") || m["level"] != "INFO" {
+		t.Errorf("json: got %s, want a valid object with a truncated msg", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	healthy := func(e Entry) bool { return !strings.Contains(e.Message, "/healthz") }
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, Filter: healthy, SampleEvery: 2, RateLimit: 2})
+
+	// Filtered entries must not use up the sampling slots or the rate limit of the others.
+	for i := 0; i < 3; i++ {
+		l.Info("GET /healthz")
+		l.Info("GET /orders")
+	}
+
+	want := []string{"INFO GET /orders", "INFO GET /orders"}
+	if got := lines(buf); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFieldSeparator(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime | Loglevel, FieldSeparator: "\t"})
+	l.setClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local) })
+
+	l.Info("hello world", "k", "v")
+
+	if got, want := buf.String(), "2024-06-01 12:00:00\tINFO\thello world k=v\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := NewLogger(&LogFileConfigs{Stdout: true, FieldSeparator: "\n"}); err == nil {
+		t.Error("NewLogger accepted a separator with a line break")
+	}
+}
+
+func TestLevelFirst(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime | Loglevel, LevelFirst: true})
+	l.setClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local) })
+
+	l.Warn("slow")
+
+	if got, want := buf.String(), "WARN 2024-06-01 12:00:00 slow\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package logger
+
+import "sync"
+
+// Hook is a callback invoked synchronously for every entry written at or above
+// the configured HookLevel, after the entry has been formatted and written.
+// Hooks only see entries that are actually written: entries below MinLevel or
+// of a disabled level, and entries dropped by sampling, rate limiting,
+// deduplication or the Filter, never reach them.
+// level: Level of the entry.
+// msg: The message of the entry.
+// fields: Key/value pairs attached to the entry, nil when there are none.
+type Hook func(level Level, msg string, fields map[string]any)
+
+// hooks is the set of hooks registered on a Logger, shared with the loggers
+// derived from it through With.
+// minLevel: Entries below this level are not passed to the hooks.
+type hooks struct {
+	mu       sync.RWMutex
+	minLevel Level
+	list     []Hook
+}
+
+// AddHook registers h to be called for every entry at or above HookLevel.
+// Hooks are shared with the loggers derived through With, and a panicking hook
+// is recovered so that it cannot break logging.
+func (l *Logger) AddHook(h Hook) {
+	if l == nil || l.hooks == nil || h == nil {
+		return
+	}
+
+	l.hooks.mu.Lock()
+	defer l.hooks.mu.Unlock()
+
+	l.hooks.list = append(l.hooks.list, h)
+}
+
+// fire calls every registered hook for the entry if its level is high enough.
+func (h *hooks) fire(level Level, e entry) {
+	if h == nil || level < h.minLevel {
+		return
+	}
+
+	h.mu.RLock()
+	list := h.list
+	h.mu.RUnlock()
+
+	if len(list) == 0 {
+		return
+	}
+
+	var fields map[string]any
+	if len(e.fields) > 0 {
+		fields = make(map[string]any, len(e.fields))
+		for _, f := range e.fields {
+			fields[f.key] = f.value
+		}
+	}
+
+	for _, hook := range list {
+		callHook(hook, level, e.msg, fields)
+	}
+}
+
+// callHook invokes hook, recovering from any panic it raises.
+func callHook(hook Hook, level Level, msg string, fields map[string]any) {
+	defer func() {
+		recover()
+	}()
+	hook(level, msg, fields)
+}
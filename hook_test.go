@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHook(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, HookLevel: ERROR})
+
+	type call struct {
+		level Level
+		msg   string
+	}
+	var calls []call
+	l.AddHook(func(Level, string, map[string]any) { panic("broken hook") })
+	l.AddHook(func(level Level, msg string, fields map[string]any) {
+		calls = append(calls, call{level, msg})
+		if fields["code"] != 42 {
+			t.Errorf("fields = %v, want code=42", fields)
+		}
+	})
+
+	l.Info("below the hook level")
+	l.Error("failed", "code", 42)
+
+	if len(calls) != 1 || calls[0] != (call{ERROR, "failed"}) {
+		t.Errorf("hook calls = %v, want one ERROR call with message %q", calls, "failed")
+	}
+	if got, want := buf.String(), "INFO below the hook level\nERROR failed code=42\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHookSeesOnlyWrittenEntries(t *testing.T) {
+	noisy := func(e Entry) bool { return e.Message != "noise" }
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, MinLevel: WARN, Filter: noisy, SampleEvery: 2})
+
+	var got []string
+	l.AddHook(func(level Level, msg string, fields map[string]any) {
+		got = append(got, level.String()+" "+msg)
+	})
+
+	l.Info("below MinLevel")
+	l.Warn("noise")
+	l.Warn("kept")
+	l.Warn("sampled out")
+	l.Error("error")
+
+	written := lines(buf)
+	if want := []string{"WARN kept", "ERROR error"}; !slices.Equal(got, want) || !slices.Equal(written, want) {
+		t.Errorf("hook saw %q and %q was written, want %q for both", got, written, want)
+	}
+}
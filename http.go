@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPMiddleware returns an http.Handler that serves requests with next and
+// logs one entry per request with its method, path, status and latency, at
+// INFO level or at ERROR level for 5xx responses. The entries carry no source
+// location, since the call site is always this middleware. Routers such as Gin and
+// chi implement http.Handler, so they can be wrapped as a whole:
+//
+//	http.ListenAndServe(":8080", log.HTTPMiddleware(router))
+func (l *Logger) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.clock()
+		rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+		latency := l.clock().Sub(start)
+
+		level := INFO
+		if rw.status >= http.StatusInternalServerError {
+			level = ERROR
+		}
+		l.logRequest(level, []any{"method", r.Method, "path", r.URL.Path, "status", rw.status, "latency", latency})
+	})
+}
+
+// clock returns the current time from the Logger's clock, or from time.Now for
+// a nil or zero-value Logger.
+func (l *Logger) clock() time.Time {
+	if l == nil || l.now == nil {
+		return time.Now()
+	}
+	return l.now()
+}
+
+// logRequest writes a request entry at the given level with the key/value
+// pairs as fields and without a source location.
+func (l *Logger) logRequest(level Level, kv []any) {
+	if w := l.enabledWriter(level); w != nil {
+		rw := *w
+		rw.noLocation = true
+		rw.write("request", toFields(kv))
+	}
+}
+
+// statusWriter records the status code written by a handler.
+// status: The status code sent to the client, 200 unless WriteHeader says otherwise.
+// wrote: Whether the header has been written, after which the status is final.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+// WriteHeader records the final status code and passes it on. Informational
+// 1xx codes may precede it and are not recorded as final.
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status = status
+		w.wrote = status >= http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write marks the header as written with the current status and passes p on.
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush marks the header as written and sends any buffered data to the client,
+// if the original ResponseWriter supports it, so that http.Flusher keeps
+// working for handlers that stream.
+func (w *statusWriter) Flush() {
+	w.wrote = true
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets the handler take over the connection, e.g. for WebSockets, if
+// the original ResponseWriter implements http.Hijacker.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the original ResponseWriter, letting http.ResponseController
+// reach optional interfaces such as http.Flusher.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
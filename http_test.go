@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel | ShortFileName | FuncName})
+	l.setClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local) })
+
+	h := l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		io.WriteString(w, "ok")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/fail", nil))
+
+	want := []string{
+		"INFO request method=GET path=/ok status=200 latency=0s",
+		"ERROR request method=POST path=/fail status=503 latency=0s",
+	}
+	if got := lines(buf); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHTTPMiddlewareOptionalInterfaces(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	h := l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flush" {
+			if _, ok := w.(http.Flusher); !ok {
+				t.Error("ResponseWriter does not implement http.Flusher")
+			}
+			io.WriteString(w, "flushed")
+			w.(http.Flusher).Flush()
+			return
+		}
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("ResponseWriter does not implement http.Hijacker")
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+		rw.Flush()
+	}))
+	// Requests are logged after the response is sent, so wait for each one to be served.
+	served := make(chan struct{}, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+		served <- struct{}{}
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/flush")
+	if err != nil {
+		t.Fatalf("GET /flush: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "flushed" {
+		t.Errorf("got body %q, want %q", body, "flushed")
+	}
+	if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("got transfer encoding %q, want chunked after Flush", resp.TransferEncoding)
+	}
+
+	resp, err = http.Get(srv.URL + "/hijack")
+	if err != nil {
+		t.Fatalf("GET /hijack: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("got status %d, want %d from the hijacked connection", resp.StatusCode, http.StatusNoContent)
+	}
+	<-served
+	<-served
+
+	if got := lines(buf); len(got) != 2 || !strings.HasPrefix(got[0], "INFO request method=GET path=/flush status=200") {
+		t.Errorf("got %q, want one entry per request", got)
+	}
+}
+
+func TestHTTPMiddlewareUninitialized(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	for _, l := range []*Logger{nil, new(Logger)} {
+		rec := httptest.NewRecorder()
+		l.HTTPMiddleware(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusTeapot {
+			t.Errorf("got status %d, want %d", rec.Code, http.StatusTeapot)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level represents the severity of a log entry.
+// Levels are ordered from the most verbose (TRACE) to the most severe (FATAL),
+// so the zero value enables every level.
+type Level int
+
+const (
+	TRACE Level = iota // Detailed trace information, useful for debugging.
+	DEBUG              // Debugging information.
+	INFO               // General informational messages.
+	WARN               // Warnings that are not critical.
+	ERROR              // Errors that require attention.
+	FATAL              // Unrecoverable errors after which the program exits.
+)
+
+// levels lists every Level in ascending order of severity.
+var levels = []Level{TRACE, DEBUG, INFO, WARN, ERROR, FATAL}
+
+// levelNames maps each Level to the label written in the log prefix.
+var levelNames = map[Level]string{
+	TRACE: "TRACE",
+	DEBUG: "DEBUG",
+	INFO:  "INFO",
+	WARN:  "WARN",
+	ERROR: "ERROR",
+	FATAL: "FATAL",
+}
+
+// ParseLevel returns the Level named by s, such as "warn" or "ERROR".
+// Names are matched case-insensitively and "warning" is accepted for WARN,
+// which allows levels to be read from configuration files and the environment.
+// Returns an error if s does not name a level.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	if name == "WARNING" {
+		return WARN, nil
+	}
+	for _, level := range levels {
+		if levelNames[level] == name {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// String returns the canonical upper-case name of the level, such as "WARN".
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// MarshalText implements encoding.TextMarshaler, so that levels are written by
+// name in JSON, YAML and other text-based configuration formats.
+func (l Level) MarshalText() ([]byte, error) {
+	if _, ok := levelNames[l]; !ok {
+		return nil, fmt.Errorf("unknown log level %d", int(l))
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLevel.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Level
+	}{
+		{"trace", TRACE},
+		{"Debug", DEBUG},
+		{"INFO", INFO},
+		{"warn", WARN},
+		{"warning", WARN},
+		{" error ", ERROR},
+		{"fatal", FATAL},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error(`ParseLevel("verbose") succeeded, want an error`)
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	for level, want := range levelNames {
+		if got := level.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", int(level), got, want)
+		}
+	}
+	if got, want := Level(42).String(), "Level(42)"; got != want {
+		t.Errorf("unknown level String() = %q, want %q", got, want)
+	}
+}
+
+func TestLevelTextRoundTrip(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+	for _, level := range levels {
+		b, err := json.Marshal(config{level})
+		if err != nil {
+			t.Fatalf("marshaling %v: %v", level, err)
+		}
+		if want := `{"level":"` + level.String() + `"}`; string(b) != want {
+			t.Errorf("marshaled %v as %s, want %s", level, b, want)
+		}
+		var got config
+		if err := json.Unmarshal(b, &got); err != nil || got.Level != level {
+			t.Errorf("unmarshaling %s = %v, %v, want %v", b, got.Level, err, level)
+		}
+	}
+
+	if _, err := Level(42).MarshalText(); err == nil {
+		t.Error("marshaling an unknown level succeeded")
+	}
+	var level Level
+	if err := level.UnmarshalText([]byte("loud")); err == nil {
+		t.Error("unmarshaling an unknown level succeeded")
+	}
+}
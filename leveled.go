@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+)
+
+// The leveled methods below are safe to call on a zero-value or nil Logger,
+// in which case they do nothing (Fatal and Fatalf still terminate the program).
+// Trace, Debug, Info, Warn, Error and Fatal take the message followed by
+// key/value pairs that are attached to that entry only, like With does for
+// every entry of a child Logger; a trailing key without a value is recorded
+// under "!BADKEY".
+
+// Trace writes msg at TRACE level with the given key/value pairs as fields.
+func (l *Logger) Trace(msg string, kv ...any) {
+	l.log(TRACE, msg, kv)
+}
+
+// Debug writes msg at DEBUG level with the given key/value pairs as fields.
+func (l *Logger) Debug(msg string, kv ...any) {
+	l.log(DEBUG, msg, kv)
+}
+
+// Info writes msg at INFO level with the given key/value pairs as fields.
+func (l *Logger) Info(msg string, kv ...any) {
+	l.log(INFO, msg, kv)
+}
+
+// Warn writes msg at WARN level with the given key/value pairs as fields.
+func (l *Logger) Warn(msg string, kv ...any) {
+	l.log(WARN, msg, kv)
+}
+
+// Warning is an alias of Warn for developers used to that spelling; Warn is
+// the canonical name.
+func (l *Logger) Warning(msg string, kv ...any) {
+	l.log(WARN, msg, kv)
+}
+
+// Error writes msg at ERROR level with the given key/value pairs as fields.
+func (l *Logger) Error(msg string, kv ...any) {
+	l.log(ERROR, msg, kv)
+}
+
+// Tracef formats the message like fmt.Sprintf and writes it at TRACE level.
+func (l *Logger) Tracef(format string, args ...any) {
+	l.printf(TRACE, format, args)
+}
+
+// Debugf formats the message like fmt.Sprintf and writes it at DEBUG level.
+func (l *Logger) Debugf(format string, args ...any) {
+	l.printf(DEBUG, format, args)
+}
+
+// Infof formats the message like fmt.Sprintf and writes it at INFO level.
+func (l *Logger) Infof(format string, args ...any) {
+	l.printf(INFO, format, args)
+}
+
+// Warnf formats the message like fmt.Sprintf and writes it at WARN level.
+func (l *Logger) Warnf(format string, args ...any) {
+	l.printf(WARN, format, args)
+}
+
+// Warningf is an alias of Warnf; Warnf is the canonical name.
+func (l *Logger) Warningf(format string, args ...any) {
+	l.printf(WARN, format, args)
+}
+
+// Errorf formats the message like fmt.Sprintf and writes it at ERROR level.
+func (l *Logger) Errorf(format string, args ...any) {
+	l.printf(ERROR, format, args)
+}
+
+// Fatal writes msg at FATAL level with the given key/value pairs as fields,
+// flushes buffered entries and then terminates the program with exit code 1.
+func (l *Logger) Fatal(msg string, kv ...any) {
+	l.log(FATAL, msg, kv)
+	l.terminate(1)
+}
+
+// FatalCode writes msg at FATAL level with the given key/value pairs as fields,
+// flushes buffered entries and then terminates the program with the given exit
+// code, so that distinct fatal conditions can be told apart by the caller.
+func (l *Logger) FatalCode(code int, msg string, kv ...any) {
+	l.log(FATAL, msg, kv)
+	l.terminate(code)
+}
+
+// Fatalf formats the message like fmt.Sprintf, writes it at FATAL level,
+// flushes buffered entries and then terminates the program with exit code 1.
+func (l *Logger) Fatalf(format string, args ...any) {
+	l.printf(FATAL, format, args)
+	l.terminate(1)
+}
+
+// TraceE writes the message at TRACE level, formatted like log.Print.
+// Returns the error reported by the underlying writers, such as a full disk,
+// so that callers can react to logging failures.
+func (l *Logger) TraceE(v ...any) error {
+	return l.write(TRACE, fmt.Sprint(v...), nil)
+}
+
+// DebugE writes the message at DEBUG level, formatted like log.Print.
+// Returns the error reported by the underlying writers.
+func (l *Logger) DebugE(v ...any) error {
+	return l.write(DEBUG, fmt.Sprint(v...), nil)
+}
+
+// InfoE writes the message at INFO level, formatted like log.Print.
+// Returns the error reported by the underlying writers.
+func (l *Logger) InfoE(v ...any) error {
+	return l.write(INFO, fmt.Sprint(v...), nil)
+}
+
+// WarnE writes the message at WARN level, formatted like log.Print.
+// Returns the error reported by the underlying writers.
+func (l *Logger) WarnE(v ...any) error {
+	return l.write(WARN, fmt.Sprint(v...), nil)
+}
+
+// ErrorE writes the message at ERROR level, formatted like log.Print.
+// Returns the error reported by the underlying writers.
+func (l *Logger) ErrorE(v ...any) error {
+	return l.write(ERROR, fmt.Sprint(v...), nil)
+}
+
+// Raw writes b verbatim to the destinations of the given level, without the
+// prefix, fields or trailing newline added to entries, e.g. for banners and
+// pre-formatted tables. Nothing is written when the level is disabled.
+// Returns the error reported by the underlying writers.
+func (l *Logger) Raw(level Level, b []byte) error {
+	if w := l.enabledWriter(level); w != nil {
+		return w.raw(b)
+	}
+	return nil
+}
+
+// Enabled reports whether entries at the given level are written, i.e. the
+// level is at or above the minimum level and has not been disabled. It lets
+// callers skip building expensive arguments for entries that would be discarded.
+func (l *Logger) Enabled(level Level) bool {
+	return l.enabledWriter(level) != nil
+}
+
+// TraceEnabled reports whether TRACE entries are written.
+func (l *Logger) TraceEnabled() bool {
+	return l.Enabled(TRACE)
+}
+
+// DebugEnabled reports whether DEBUG entries are written:
+//
+//	if l.DebugEnabled() {
+//		l.Debug("state", "dump", dumpState())
+//	}
+func (l *Logger) DebugEnabled() bool {
+	return l.Enabled(DEBUG)
+}
+
+// InfoEnabled reports whether INFO entries are written.
+func (l *Logger) InfoEnabled() bool {
+	return l.Enabled(INFO)
+}
+
+// WarnEnabled reports whether WARN entries are written.
+func (l *Logger) WarnEnabled() bool {
+	return l.Enabled(WARN)
+}
+
+// ErrorEnabled reports whether ERROR entries are written.
+func (l *Logger) ErrorEnabled() bool {
+	return l.Enabled(ERROR)
+}
+
+// log writes msg at the given level with the key/value pairs as fields.
+// The fields are only collected when the level is enabled.
+func (l *Logger) log(level Level, msg string, kv []any) {
+	if w := l.enabledWriter(level); w != nil {
+		w.write(msg, toFields(kv))
+	}
+}
+
+// printf formats args like fmt.Sprintf and writes the result at the given level.
+// The message is only formatted when the level is enabled.
+func (l *Logger) printf(level Level, format string, args []any) {
+	if w := l.enabledWriter(level); w != nil {
+		w.write(fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// write writes msg with the extra fields at the given level, unless the level
+// is below the minimum level or disabled.
+// Returns any error reported by the underlying writers.
+func (l *Logger) write(level Level, msg string, fields []field) error {
+	if w := l.enabledWriter(level); w != nil {
+		return w.write(msg, fields)
+	}
+	return nil
+}
+
+// enabledWriter returns the writer of the given level, or nil if the level is
+// below the minimum level, disabled, or the Logger has not been initialized.
+func (l *Logger) enabledWriter(level Level) *levelWriter {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level < l.minLevel || l.disabled[level] {
+		return nil
+	}
+	return l.writers[level]
+}
+
+// terminate flushes buffered entries and ends the program with the given code
+// using the Logger's exit function, or os.Exit if none is set.
+func (l *Logger) terminate(code int) {
+	exit := os.Exit
+	if l != nil {
+		l.Flush()
+		if l.exit != nil {
+			exit = l.exit
+		}
+	}
+	exit(code)
+}
@@ -1,17 +1,25 @@
 package logger
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
-	"runtime"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
 // logSyntax is a bitmask used to define the format of log messages.
+// ShortFileName and LongFileName are mutually exclusive; NewLogger rejects a
+// mask that contains both.
 type logSyntax uint32
 
 const (
@@ -19,18 +27,234 @@ const (
 	Loglevel                            // Include log level in the log entry.
 	ShortFileName                       // Include short (basename) file name in the log entry.
 	LongFileName                        // Include full file path in the log entry.
+	FuncName                            // Include the name of the calling function in the log entry.
+	ElapsedTime                         // Include the time elapsed since the Logger was created instead of the date and time.
+	Milliseconds                        // Include milliseconds in the date and time.
+	Microseconds                        // Include microseconds in the date and time; takes precedence over Milliseconds.
+	ShortLevel                          // Abbreviate the level to its first letter (I, W, E, ...); LevelNames take precedence.
+	GoroutineID                         // Include the ID of the goroutine that wrote the entry (g42); costly, opt-in only.
+	Hostname                            // Include the name of the host, resolved once when the Logger is created.
+	PID                                 // Include the ID of the process.
+)
+
+// Preset combinations of the syntax elements, mirroring log.LstdFlags.
+const (
+	StdFlags  = DateTime | Loglevel | ShortFileName // Date and time, level and short file name.
+	FullFlags = DateTime | Loglevel | LongFileName  // Date and time, level and full file path.
+)
+
+// validate reports an error if the bitmask contains conflicting syntax elements.
+func (s logSyntax) validate() error {
+	if s&ShortFileName != 0 && s&LongFileName != 0 {
+		return errors.New("include cannot contain both ShortFileName and LongFileName")
+	}
+	return nil
+}
+
+// logFormat selects how log entries are rendered.
+type logFormat uint32
+
+const (
+	FormatText logFormat = iota // Render entries as a space-separated prefix followed by the message.
+	FormatJSON                  // Render each entry as a single-line JSON object.
 )
 
 // LogFileConfigs encapsulates the configuration options for the Logger.
-// Directory: Directory path where the log file will be created.
+// Directory: Directory path where the log file will be created; relative paths
+// are resolved against the working directory. Required unless file names are absolute.
 // Filename: Name of the log file.
 // Stdout: Whether to also log to standard output.
 // Include: Bitmask to define which syntax elements to include in the log entry.
+// MinLevel: Minimum level that is written; entries below it are discarded.
+// Format: Output format of each entry, FormatText (default) or FormatJSON.
+// MaxSizeMB: Size in megabytes after which the log file is rotated, 0 disables rotation.
+// MaxBackups: Maximum number of rotated files to keep, 0 keeps all of them. With RotateDaily it
+// also limits the number of previous days whose files are kept.
+// MaxTotalSizeMB: Delete the oldest rotated files once all log files together exceed this size, 0 disables the cap.
+// RotateDaily: Whether to start a new log file, named with a date suffix, every day.
+// Output: Custom destination (buffer, socket, ...) written in addition to the file, if any.
+// Color: Whether to colorize the level on stdout; ignored when stdout is not a terminal.
+// TimeFormat: Layout used to render timestamps, "2006-01-02 15:04:05" when empty.
+// UTC: Whether timestamps are rendered in UTC instead of local time.
+// SyslogTag: When set, entries are also sent to the system log under this tag,
+// with a severity matching their level and without the time, which the system log records itself.
+// Not supported on Windows and Plan 9.
+// Compress: Whether rotated log files are gzipped.
+// LevelFiles: File names, relative to Directory, of levels that are written to a
+// file of their own instead of Filename (e.g. {ERROR: "error.log"}).
+// ContextKeys: Keys whose context values are added as fields by InfoContext and friends.
+// DirPerm: Permissions of a created log directory, 0755 when zero.
+// FilePerm: Permissions of created log files, 0644 when zero.
+// HookLevel: Minimum level of the entries passed to hooks registered with AddHook.
+// SampleEvery: Write only 1 in every SampleEvery entries per level, 0 or 1 writes all of them.
+// Dedup: Replace consecutive identical entries with a "last message repeated N times" entry.
+// RateLimit: Maximum number of entries written per second at each level, 0 for no limit.
+// The excess is dropped and reported by the next entry let through.
+// RateBurst: Number of entries that may be written at once before RateLimit applies,
+// defaults to RateLimit rounded up.
+// LevelNames: Labels that replace the default label of individual levels (e.g. {WARN: "warning"}).
+// Prefix: Static token, such as "[auth]", written on every line between the timestamp and the level.
+// ErrorHandler: Called with the error whenever writing an entry fails, e.g. because the disk is full.
+// Calls are serialized; failures caused by the handler logging through the Logger are dropped.
+// Sinks: Additional log files with rotation policies of their own, each receiving a chosen set of levels.
+// CallerSkip: Number of additional stack frames skipped when reporting the source location,
+// for wrappers that call the Logger on behalf of their own callers.
+// RedactKeys: Field keys, matched case-insensitively, whose values are written as "***".
+// RedactPatterns: Expressions whose matches in messages are written as "***".
+// LevelEnv: Environment variable whose level name, when set, overrides MinLevel; LOG_LEVEL when empty.
+// TraceExtractor: Returns the trace and span IDs carried by a context, added as
+// trace_id and span_id by InfoContext and friends.
+// EpochTime: Whether JSON entries carry the time as an integer of nanoseconds since the Unix epoch.
+// Observe: Whether written entries are captured in memory for retrieval with Observed, e.g. in tests.
+// FieldKeys: Names of the standard members of JSON entries, e.g. "message" instead of "msg".
+// FallbackToStdout: Whether to log to stdout instead of failing when the log files cannot be
+// opened, e.g. in a read-only directory. The failure is reported to ErrorHandler, or to stderr.
+// Encoder: Custom encoder rendering entries in place of Format, e.g. logfmt; nil uses Format.
+// Async: Whether entries are written by a background goroutine instead of on the caller's path.
+// QueueSize: Number of entries the Async queue holds, 1024 by default.
+// DropOnFullQueue: Whether entries are dropped and counted instead of blocking the caller when the
+// Async queue is full; see DroppedEntries. FATAL entries always wait for room.
+// EventLogSource: When set on Windows, entries are also reported to the Windows Event Log under
+// this source, as errors, warnings or information depending on their level, and without the time.
+// EventLogLevel: Minimum level of the entries reported to the Windows Event Log, WARN when zero.
+// Since the zero Level is TRACE, DEBUG is the lowest level that can be selected.
+// MaxLineBytes: Length in bytes above which entries are truncated and marked with
+// "...[truncated N bytes]"; in JSON only the message is shortened, and the color codes of
+// stdout entries are not counted. 0 disables truncation.
+// Filter: Predicate called with every entry before it is written; returning false drops the entry.
+// It runs before sampling and rate limiting, so dropped entries count towards neither.
+// RotateNameFunc: Names rotated files in place of the numbered app.log.1 scheme, given the path of
+// the active file, the time of rotation and a sequence number starting at 1 that is increased until
+// the name is free; see TimestampRotateName. MaxBackups and MaxTotalSizeMB then only account for the
+// files rotated since the Logger was created.
+// ValueFormatters: Render field values of chosen types, see FormatType. time.Duration values are
+// always rendered like 1.5s unless a formatter for them is given.
+// NoMkdir: Whether to require the log directories to exist instead of creating them, e.g. in
+// sandboxes that forbid creating directories.
+// FieldSeparator: Written after every component of the text prefix, such as the time, level and
+// file, in place of a single space; "\t" produces tab-separated columns. It is also written between
+// the prefix and the message, and cannot contain line breaks.
+// LevelFirst: Whether the level is written as the first token of text entries, ahead of the
+// timestamp, for log shippers that filter on it without parsing the time.
+// SyncLevel: Entries at or above this level are flushed and synced to disk immediately; TRACE (zero) disables it.
+// BufferSize: Size in bytes of a write buffer in front of the log file, 0 disables buffering.
+// FlushInterval: How often buffered entries are flushed in the background, 0 disables the flusher.
 type LogFileConfigs struct {
-	Directory string
-	Filename  string
-	Stdout    bool
-	Include   logSyntax
+	Directory        string
+	Filename         string
+	Stdout           bool
+	Include          logSyntax
+	MinLevel         Level
+	Format           logFormat
+	MaxSizeMB        int
+	MaxBackups       int
+	MaxTotalSizeMB   int
+	RotateDaily      bool
+	Output           io.Writer
+	Color            bool
+	TimeFormat       string
+	UTC              bool
+	SyslogTag        string
+	Compress         bool
+	BufferSize       int
+	FlushInterval    time.Duration
+	LevelFiles       map[Level]string
+	ContextKeys      []any
+	DirPerm          os.FileMode
+	FilePerm         os.FileMode
+	HookLevel        Level
+	SampleEvery      int
+	Dedup            bool
+	RateLimit        float64
+	RateBurst        int
+	LevelNames       map[Level]string
+	Prefix           string
+	ErrorHandler     func(error)
+	SyncLevel        Level
+	Sinks            []Sink
+	CallerSkip       int
+	RedactKeys       []string
+	RedactPatterns   []*regexp.Regexp
+	LevelEnv         string
+	TraceExtractor   TraceExtractor
+	EpochTime        bool
+	Observe          bool
+	FieldKeys        FieldKeys
+	FallbackToStdout bool
+	Encoder          Encoder
+	Async            bool
+	QueueSize        int
+	DropOnFullQueue  bool
+	EventLogSource   string
+	EventLogLevel    Level
+	MaxLineBytes     int
+	Filter           func(Entry) bool
+	RotateNameFunc   func(base string, t time.Time, seq int) string
+	ValueFormatters  []ValueFormatter
+	NoMkdir          bool
+	FieldSeparator   string
+	LevelFirst       bool
+}
+
+// Sink configures an additional log file that receives the entries of a chosen
+// set of levels and is rotated independently of the main log file, e.g. an
+// audit log kept longer than the application log.
+// Filename: Name of the log file, relative to Directory unless absolute.
+// Levels: Levels written to the file, every level when empty.
+// MaxSizeMB: Size in megabytes after which the file is rotated, 0 disables rotation.
+// MaxBackups: Maximum number of rotated files to keep, 0 keeps all of them. With RotateDaily it
+// also limits the number of previous days whose files are kept.
+// MaxTotalSizeMB: Delete the oldest rotated files once the file and its backups exceed this size, 0 disables the cap.
+// RotateDaily: Whether to start a new file, named with a date suffix, every day.
+// Compress: Whether rotated files are gzipped.
+type Sink struct {
+	Filename       string
+	Levels         []Level
+	MaxSizeMB      int
+	MaxBackups     int
+	MaxTotalSizeMB int
+	RotateDaily    bool
+	Compress       bool
+}
+
+// FieldKeys renames the standard members of JSON entries to match an existing
+// schema. Empty names keep their default.
+// Time: Name of the time member, "time" by default.
+// Level: Name of the level member, "level" by default.
+// Message: Name of the message member, "msg" by default.
+// File: Name of the file member, "file" by default.
+// Line: Name of the line member, "line" by default.
+// Func: Name of the function member, "func" by default.
+type FieldKeys struct {
+	Time    string
+	Level   string
+	Message string
+	File    string
+	Line    string
+	Func    string
+}
+
+// withDefaults returns k with every empty name replaced by its default.
+func (k FieldKeys) withDefaults() FieldKeys {
+	if k.Time == "" {
+		k.Time = "time"
+	}
+	if k.Level == "" {
+		k.Level = "level"
+	}
+	if k.Message == "" {
+		k.Message = "msg"
+	}
+	if k.File == "" {
+		k.File = "file"
+	}
+	if k.Line == "" {
+		k.Line = "line"
+	}
+	if k.Func == "" {
+		k.Func = "func"
+	}
+	return k
 }
 
 // Logger holds loggers for different log levels.
@@ -39,20 +263,86 @@ type LogFileConfigs struct {
 // WARN: Logger for warnings that are not critical.
 // ERROR: Logger for errors that require attention.
 // TRACE: Logger for detailed trace information, useful for debugging.
+// FATAL: Logger for unrecoverable errors, see Fatal.
+//
+// A Logger is safe for concurrent use by multiple goroutines. Every entry is
+// written with a single call to each destination, so lines logged from
+// different goroutines are never interleaved. The log file, its rotation, the
+// level settings and writes to stdout and to a custom Output are guarded by a
+// mutex, so entries stay intact even when stdout is a pipe.
+//
+// A zero-value Logger discards every entry. Its methods, including With,
+// SetMinLevel, SetLevelEnabled, SetOutput, SetInclude and TailBuffer, are safe
+// to call but leave it, and the loggers derived from it, discarding entries;
+// use NewLogger to create a Logger that writes.
 type Logger struct {
 	DEBUG *log.Logger
 	INFO  *log.Logger
 	WARN  *log.Logger
 	ERROR *log.Logger
 	TRACE *log.Logger
+	FATAL *log.Logger
+
+	mu         sync.Mutex                // Guards the fields below.
+	file       *rotatingFile             // Log file opened by NewLogger, nil when logging only to stdout.
+	levelFiles map[Level]*rotatingFile   // Files of levels that write to their own file instead of file.
+	sinkFiles  map[Level][]*rotatingFile // Files of the configured sinks receiving each level.
+	closed     bool                      // Whether Close has already been called.
+	derived    bool                      // Whether the Logger was created by With and shares its parent's resources.
+	writers    map[Level]*levelWriter    // Destination of each level while it is enabled.
+	levelOuts  map[Level]io.Writer       // Additional destination of individual levels, such as syslog or the event log.
+	closers    []io.Closer               // Resources other than the log file released by Close.
+	minLevel   Level                     // Entries below this level are discarded.
+	disabled   map[Level]bool            // Levels turned off individually, see SetLevelEnabled.
+	exit       func(int)                 // Terminates the program after a fatal entry, os.Exit by default.
+	out        io.Writer                 // Destination shared by all levels apart from log files (stdout, Output).
+	colorOut   io.Writer                 // Destination receiving colorized entries, nil when color is disabled.
+	formatter  formatter                 // Settings that control how entries are rendered.
+	fields     []field                   // Key/value pairs attached to every entry, see With.
+	now        func() time.Time          // Clock used for timestamps and daily rotation, time.Now by default.
+	ctxKeys    []any                     // Context keys whose values are logged by the *Context methods.
+	hooks      *hooks                    // Callbacks invoked for every entry, shared with derived loggers.
+	sampler    *sampler                  // Drops entries when sampling is enabled, shared with derived loggers.
+	dedup      *deduper                  // Suppresses repeated entries when enabled, shared with derived loggers.
+	onError    *errorHandler             // Receives write failures when an ErrorHandler is configured.
+	syncLevel  Level                     // Entries at or above this level are synced to disk, TRACE disables syncing.
+	callerSkip int                       // Additional stack frames skipped when reporting the source location.
+	redactor   *redactor                 // Masks sensitive data, nil when nothing is redacted.
+	tail       *ringBuffer               // Retains the most recent entries, see TailBuffer.
+	tracing    TraceExtractor            // Extracts trace and span IDs from contexts, nil when not configured.
+	observer   *observer                 // Captures written entries when Observe is set, shared with derived loggers.
+	limiter    *rateLimiter              // Drops entries exceeding RateLimit, shared with derived loggers.
+	async      *asyncQueue               // Background writer used when Async is set, shared with derived loggers.
+	filter     func(Entry) bool          // Drops the entries it returns false for, nil when not configured.
+	config     LogFileConfigs            // Effective configuration the Logger was created with, see Config.
+	values     valueFormatters           // Render field values by their type.
+	leveled    []*syncWriter             // Outputs implementing LevelWriter, given the level of every entry.
 }
 
+// defaultSyntax is the prefix used when NewLogger is called without a configuration.
+const defaultSyntax = StdFlags
+
+// defaultLevelEnv is the environment variable read when LevelEnv is empty.
+const defaultLevelEnv = "LOG_LEVEL"
+
+// ErrClosed is returned by Close when the Logger has already been closed.
+var ErrClosed = errors.New("logger is already closed")
+
+// ErrCloseTimeout is returned by CloseWithTimeout when pending entries could not
+// be written before the deadline.
+var ErrCloseTimeout = errors.New("logger close timed out with pending entries")
+
 // NewLogger initializes a Logger based on the provided configuration.
 // It creates log files, sets up multi-writer for simultaneous stdout/file logging,
 // and assigns loggers for various levels.
 // Returns the initialized Logger and an error if any issues are encountered during setup.
 func NewLogger(config *LogFileConfigs) (*Logger, error) {
-	l := &Logger{}
+	l := &Logger{
+		exit:      os.Exit,
+		now:       time.Now,
+		formatter: formatter{syntax: defaultSyntax, layout: timeLayout, timeFormat: timeLayout, keys: FieldKeys{}.withDefaults()},
+		hooks:     &hooks{},
+	}
 
 	// Get the current working directory to construct the log file path.
 	wd, err := os.Getwd()
@@ -60,83 +350,854 @@ func NewLogger(config *LogFileConfigs) (*Logger, error) {
 		return nil, err
 	}
 
-	// Default to logging to standard output.
-	multiWriter := io.MultiWriter(os.Stdout)
+	// Stdout and custom outputs are shared by every level; log files are
+	// attached per level by newLevelWriter.
+	l.out, l.colorOut, l.leveled = outputs(config)
 
-	// If configuration is provided, set up file logging.
+	// If configuration is provided, set up file and custom output logging.
 	if config != nil {
-		if config.Filename == "" {
-			return nil, errors.New("filename is required")
+		if !config.Stdout && config.Filename == "" && config.Output == nil && config.SyslogTag == "" &&
+			config.EventLogSource == "" && len(config.LevelFiles) == 0 && len(config.Sinks) == 0 {
+			return nil, errors.New("filename, stdout, output, syslog tag, event log source or sink is required")
+		}
+		if err := config.Include.validate(); err != nil {
+			return nil, err
+		}
+		if strings.ContainsAny(config.FieldSeparator, "\r\n") {
+			return nil, errors.New("field separator cannot contain line breaks")
+		}
+
+		// Send each level to the system log with the matching severity.
+		if config.SyslogTag != "" {
+			syslogWriters, conn, err := newSyslogWriters(config.SyslogTag)
+			if err != nil {
+				return nil, err
+			}
+			l.levelOuts = syslogWriters
+			l.closers = append(l.closers, conn)
+		}
+
+		// Report entries to the Windows Event Log with the matching event type.
+		if config.EventLogSource != "" {
+			eventWriters, eventLog, err := newEventLogWriters(config.EventLogSource, eventLogLevel(config.EventLogLevel))
+			if err != nil {
+				l.Close()
+				return nil, err
+			}
+			if l.levelOuts == nil {
+				l.levelOuts = make(map[Level]io.Writer)
+			}
+			for level, w := range eventWriters {
+				if out, ok := l.levelOuts[level]; ok {
+					w = io.MultiWriter(out, w)
+				}
+				l.levelOuts[level] = w
+			}
+			l.closers = append(l.closers, eventLog)
+		}
+
+		if err := l.openFiles(wd, config); err != nil {
+			if !config.FallbackToStdout {
+				l.Close()
+				return nil, err
+			}
+
+			// Degrade to stdout rather than keep the application from starting.
+			for _, f := range l.files() {
+				f.Close()
+			}
+			l.file, l.levelFiles, l.sinkFiles = nil, nil, nil
+			if !config.Stdout {
+				l.out = io.MultiWriter(l.out, stdout)
+			}
+			err = fmt.Errorf("logger: falling back to stdout: %w", err)
+			if config.ErrorHandler != nil {
+				newErrorHandler(config.ErrorHandler).report(err)
+			} else {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+
+		l.formatter = formatter{
+			format:     config.Format,
+			layout:     config.TimeFormat,
+			utc:        config.UTC,
+			levelNames: maps.Clone(config.LevelNames),
+			prefix:     config.Prefix,
+			epoch:      config.EpochTime,
+			keys:       config.FieldKeys.withDefaults(),
+			encoder:    config.Encoder,
+			maxLine:    config.MaxLineBytes,
+			separator:  config.FieldSeparator,
+			levelFirst: config.LevelFirst,
+		}
+		if l.formatter.layout == "" {
+			l.formatter.layout = timeLayout
+		}
+		l.formatter.setSyntax(config.Include)
+		l.formatter.pid = os.Getpid()
+		l.minLevel = config.MinLevel
+		l.ctxKeys = config.ContextKeys
+		l.tracing = config.TraceExtractor
+		l.observer = newObserver(config.Observe)
+		l.hooks.minLevel = config.HookLevel
+		l.sampler = newSampler(config.SampleEvery)
+		l.dedup = newDeduper(config.Dedup)
+		l.limiter = newRateLimiter(config.RateLimit, config.RateBurst)
+		l.onError = newErrorHandler(config.ErrorHandler)
+		l.syncLevel = config.SyncLevel
+		l.callerSkip = config.CallerSkip
+		l.redactor = newRedactor(config.RedactKeys, config.RedactPatterns)
+		l.filter = config.Filter
+		l.values = newValueFormatters(config.ValueFormatters)
+		l.async = newAsyncQueue(config.Async, config.QueueSize, config.DropOnFullQueue, l.onError)
+	}
+
+	// The environment overrides the configured minimum level, e.g. LOG_LEVEL=debug.
+	levelEnv := defaultLevelEnv
+	if config != nil && config.LevelEnv != "" {
+		levelEnv = config.LevelEnv
+	}
+	if value, ok := os.LookupEnv(levelEnv); ok {
+		if level, err := ParseLevel(value); err == nil {
+			l.minLevel = level
+		} else {
+			fmt.Fprintf(os.Stderr, "logger: ignoring %s: %v\n", levelEnv, err)
+		}
+	}
+
+	l.formatter.start = l.now()
+	l.config = l.effectiveConfig(config, wd)
+	l.initLevels()
+
+	return l, nil
+}
+
+// MustNewLogger is like NewLogger but panics if the Logger cannot be created.
+// It suits simple programs for which a logging failure is fatal anyway:
+//
+//	var log = logger.MustNewLogger(&logger.LogFileConfigs{Directory: "logs", Filename: "app.log"})
+func MustNewLogger(config *LogFileConfigs) *Logger {
+	l, err := NewLogger(config)
+	if err != nil {
+		panic("logger: " + err.Error())
+	}
+	return l
+}
+
+// stdout serializes the writes of every Logger to standard output. An entry may
+// need several write system calls when stdout is a pipe, and without the lock
+// the partial writes of concurrent entries could interleave.
+var stdout io.Writer = &syncWriter{w: os.Stdout}
+
+// outputs builds the destinations shared by every level apart from log files.
+// Without a configuration entries go to stdout. Otherwise stdout is included
+// when Stdout is set, as colorOut when color is enabled for a terminal, and
+// Output is included when set, or returned in leveled when it is a LevelWriter.
+// Returns the writer for plain entries and the writer for colorized entries, nil when color is disabled.
+func outputs(config *LogFileConfigs) (out, colorOut io.Writer, leveled []*syncWriter) {
+	if config == nil {
+		return stdout, nil, nil
+	}
+
+	var writers []io.Writer
+	if config.Stdout {
+		if config.Color && config.Format == FormatText && isTerminal(os.Stdout) {
+			colorOut = stdout
+		} else {
+			writers = append(writers, stdout)
+		}
+	}
+	if config.Output != nil {
+		if _, ok := config.Output.(LevelWriter); ok {
+			leveled = append(leveled, &syncWriter{w: config.Output})
+		} else {
+			writers = append(writers, &syncWriter{w: config.Output})
+		}
+	}
+	return io.MultiWriter(writers...), colorOut, leveled
+}
+
+// openFiles opens the main log file and the files of levels listed in
+// LevelFiles, rotating them once they grow past MaxSizeMB and, if requested,
+// every day. Levels sharing a file name share the opened file.
+// Files opened before an error are left for Close to release.
+func (l *Logger) openFiles(wd string, config *LogFileConfigs) error {
+	if config.Filename != "" {
+		file, err := openLogFile(wd, config, config.Filename)
+		if err != nil {
+			return err
 		}
+		l.file = file
+	}
 
-		// Construct the full path for the log file.
-		path := filepath.Join(wd, config.Directory, config.Filename)
+	// Levels with a file of their own write there instead of the main file.
+	opened := make(map[string]*rotatingFile)
+	for level, name := range config.LevelFiles {
+		if name == config.Filename {
+			continue
+		}
+		if _, ok := opened[name]; !ok {
+			file, err := openLogFile(wd, config, name)
+			if err != nil {
+				return err
+			}
+			opened[name] = file
+		}
+		if l.levelFiles == nil {
+			l.levelFiles = make(map[Level]*rotatingFile)
+		}
+		l.levelFiles[level] = opened[name]
+	}
+
+	// Every sink has a file of its own with its own rotation policy.
+	for _, sink := range config.Sinks {
+		if sink.Filename == "" {
+			return errors.New("sink filename is required")
+		}
+		if _, ok := opened[sink.Filename]; ok || sink.Filename == config.Filename {
+			return errors.New("sink filename must not be shared with another log file")
+		}
 
-		// Ensure the directory exists or create it.
-		err = os.MkdirAll(filepath.Dir(path), 0755)
+		sinkConfig := *config
+		sinkConfig.MaxSizeMB = sink.MaxSizeMB
+		sinkConfig.MaxBackups = sink.MaxBackups
+		sinkConfig.MaxTotalSizeMB = sink.MaxTotalSizeMB
+		sinkConfig.RotateDaily = sink.RotateDaily
+		sinkConfig.Compress = sink.Compress
+		file, err := openLogFile(wd, &sinkConfig, sink.Filename)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		opened[sink.Filename] = file
 
-		// Open the log file for writing.
-		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		sinkLevels := sink.Levels
+		if len(sinkLevels) == 0 {
+			sinkLevels = levels
+		}
+		if l.sinkFiles == nil {
+			l.sinkFiles = make(map[Level][]*rotatingFile)
+		}
+		for _, level := range sinkLevels {
+			if !slices.Contains(l.sinkFiles[level], file) {
+				l.sinkFiles[level] = append(l.sinkFiles[level], file)
+			}
+		}
+	}
+	return nil
+}
+
+// NewLoggerFromWriter initializes a Logger that writes every level to w using
+// the given syntax, without any file handling. It is a lightweight alternative
+// to NewLogger for libraries and tests that accept an arbitrary sink.
+// Returns the initialized Logger and an error if w is nil or include is invalid.
+func NewLoggerFromWriter(w io.Writer, include logSyntax) (*Logger, error) {
+	if w == nil {
+		return nil, errors.New("writer is required")
+	}
+	return NewLogger(&LogFileConfigs{Output: w, Include: include})
+}
+
+// NewNopLogger returns a fully initialized Logger that discards every entry.
+// It is useful for libraries and tests that need a *Logger but no output;
+// every level writes to io.Discard, so calls are safe and nearly free.
+// Fatal and Fatalf still terminate the program.
+func NewNopLogger() *Logger {
+	l := &Logger{
+		exit:      os.Exit,
+		now:       time.Now,
+		out:       io.Discard,
+		formatter: formatter{layout: timeLayout, timeFormat: timeLayout, keys: FieldKeys{}.withDefaults()},
+		disabled:  make(map[Level]bool),
+		hooks:     &hooks{},
+	}
+	for _, level := range levels {
+		l.disabled[level] = true
+	}
+	l.initLevels()
+
+	return l
+}
+
+// NewTestLogger returns a Logger writing entries of every level, with the
+// default prefix, to the returned buffer, for tests that assert on the output:
+//
+//	log, buf := logger.NewTestLogger()
+//	log.Info("started")
+//	if !strings.Contains(buf.String(), "started") {
+//		t.Error("entry not logged")
+//	}
+//
+// LOG_LEVEL is ignored so that the environment cannot hide entries. The buffer
+// must not be read while other goroutines are still logging.
+func NewTestLogger() (*Logger, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	l := MustNewLogger(&LogFileConfigs{Output: buf, Include: defaultSyntax})
+	l.SetMinLevel(TRACE)
+	return l, buf
+}
+
+// openLogFile creates the directory of the named log file if needed and opens
+// the file with the rotation settings of config.
+// A relative name requires an explicit Directory, so that a library cannot
+// create files in whatever directory the host application happens to run in;
+// Directory "." opts in to the working directory.
+// wd: Working directory relative directories and file names are resolved against.
+// Returns the opened file and an error if the location is not explicit, the
+// directory is missing with NoMkdir or the directory or the file could not be created.
+func openLogFile(wd string, config *LogFileConfigs, name string) (*rotatingFile, error) {
+	if config.Directory == "" && !filepath.IsAbs(name) {
+		return nil, fmt.Errorf("log file %q needs a directory: set Directory or use an absolute path", name)
+	}
+	path := logFilePath(wd, config, name)
+
+	// With NoMkdir the directory has to exist already, otherwise create it.
+	dir := filepath.Dir(path)
+	if config.NoMkdir {
+		info, err := os.Stat(dir)
 		if err != nil {
+			return nil, fmt.Errorf("log directory %s must exist when NoMkdir is set: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("log directory %s is not a directory", dir)
+		}
+	} else {
+		perm := config.DirPerm
+		if perm == 0 {
+			perm = 0755
+		}
+		if err := os.MkdirAll(dir, perm); err != nil {
 			return nil, err
 		}
+	}
+
+	return openRotatingFile(path, config)
+}
+
+// logFilePath resolves the full path of the named log file. An absolute name
+// is used as is, otherwise it is placed in config.Directory, which in turn is
+// resolved against wd unless it is absolute itself.
+func logFilePath(wd string, config *LogFileConfigs, name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+
+	path := filepath.Join(config.Directory, name)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(wd, path)
+	}
+	return path
+}
 
-		// Setup the writer to write to both stdout and the file if specified.
-		if config.Stdout {
-			multiWriter = io.MultiWriter(os.Stdout, file)
+// initLevels creates the writer and *log.Logger of every level from the
+// Logger's settings and applies the minimum level. Entries are formatted by
+// levelWriter on every write so that timestamps reflect when each entry was logged.
+// A zero-value Logger and the loggers derived from it stay uninitialized and
+// discard every entry.
+func (l *Logger) initLevels() {
+	if !l.initialized() {
+		return
+	}
+
+	l.writers = make(map[Level]*levelWriter)
+	for _, level := range levels {
+		l.writers[level] = l.newLevelWriter(level)
+	}
+
+	l.INFO = log.New(l.writers[INFO], "", 0)
+	l.WARN = log.New(l.writers[WARN], "", 0)
+	l.ERROR = log.New(l.writers[ERROR], "", 0)
+	l.DEBUG = log.New(l.writers[DEBUG], "", 0)
+	l.TRACE = log.New(l.writers[TRACE], "", 0)
+	l.FATAL = log.New(l.writers[FATAL], "", 0)
+
+	l.SetMinLevel(l.minLevel)
+}
+
+// With returns a child Logger that appends the given key/value pairs to every
+// entry it writes, as key=value in text mode or as object members in JSON mode.
+// Fields accumulate when With is chained and are always rendered in the order
+// they were added, parent fields first, followed by the fields given to the
+// logging call. A trailing key without a value is recorded under the key
+// "!BADKEY".
+// The child writes to the same destinations and starts with the parent's
+// minimum level and enabled levels; closing the child does not close the
+// parent's log file.
+func (l *Logger) With(kv ...any) *Logger {
+	child := l.derive()
+	child.fields = append(child.fields, toFields(kv)...)
+	child.initLevels()
+	return child
+}
+
+// WithCallerSkip returns a child Logger that skips skip additional stack frames
+// when reporting the source location, on top of the parent's CallerSkip. It lets
+// helpers wrapping the Logger report the line that called the helper:
+//
+//	func logFailure(l *logger.Logger, err error) {
+//		l.WithCallerSkip(1).Error("request failed", "err", err)
+//	}
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	child := l.derive()
+	child.callerSkip += skip
+	child.initLevels()
+	return child
+}
+
+// Config returns the effective configuration of the Logger: the configuration
+// it was created with, with the defaults that were filled in, relative
+// directories resolved, the minimum level overridden by the environment and
+// the syntax changed by SetInclude. It is meant for diagnostics such as logging
+// the active settings at startup.
+func (l *Logger) Config() LogFileConfigs {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	config := l.config
+	config.MinLevel = l.minLevel
+	config.Include = l.formatter.syntax
+	return config
+}
+
+// effectiveConfig returns config with its defaults filled in, as used by l.
+// wd is the working directory relative directories are resolved against.
+func (l *Logger) effectiveConfig(config *LogFileConfigs, wd string) LogFileConfigs {
+	if config == nil {
+		return LogFileConfigs{Stdout: true, Include: defaultSyntax, TimeFormat: timeLayout,
+			LevelEnv: defaultLevelEnv, FieldKeys: FieldKeys{}.withDefaults(), FieldSeparator: " "}
+	}
+
+	c := *config
+	c.LevelFiles = maps.Clone(config.LevelFiles)
+	c.LevelNames = maps.Clone(config.LevelNames)
+	c.Sinks = slices.Clone(config.Sinks)
+	c.ContextKeys = slices.Clone(config.ContextKeys)
+	c.RedactKeys = slices.Clone(config.RedactKeys)
+	c.RedactPatterns = slices.Clone(config.RedactPatterns)
+
+	if c.Directory != "" && !filepath.IsAbs(c.Directory) {
+		c.Directory = filepath.Join(wd, c.Directory)
+	}
+	// Without its log file, a Logger configured with FallbackToStdout logs to stdout.
+	if c.Filename != "" && l.file == nil {
+		c.Stdout = true
+	}
+	c.TimeFormat = l.formatter.layout
+	c.FieldKeys = l.formatter.keys
+	if c.DirPerm == 0 {
+		c.DirPerm = 0755
+	}
+	if c.FilePerm == 0 {
+		c.FilePerm = 0644
+	}
+	if c.FieldSeparator == "" {
+		c.FieldSeparator = " "
+	}
+	if c.LevelEnv == "" {
+		c.LevelEnv = defaultLevelEnv
+	}
+	if l.limiter != nil {
+		c.RateBurst = int(l.limiter.burst)
+	}
+	if c.EventLogSource != "" {
+		c.EventLogLevel = eventLogLevel(c.EventLogLevel)
+	}
+	if c.Async && c.QueueSize <= 0 {
+		c.QueueSize = defaultQueueSize
+	}
+	return c
+}
+
+// WithOutput returns a child Logger whose entries go to w in addition to the
+// parent's destinations, for example to copy everything a subsystem logs to a
+// dedicated file. The child owns w: closing the child closes w if it is an
+// io.Closer, while the parent's files stay open. Loggers derived from the child
+// write to w as well but do not close it.
+func (l *Logger) WithOutput(w io.Writer) *Logger {
+	child := l.derive()
+	if w != nil {
+		if _, ok := w.(LevelWriter); ok {
+			child.leveled = append(child.leveled[:len(child.leveled):len(child.leveled)], &syncWriter{w: w})
 		} else {
-			multiWriter = io.MultiWriter(file)
+			child.out = io.MultiWriter(child.out, &syncWriter{w: w})
+		}
+		if c, ok := w.(io.Closer); ok {
+			child.closers = append(child.closers, c)
 		}
+	}
+	child.initLevels()
+	return child
+}
 
-		// Initialize loggers for each level with the appropriate prefix and multi-writer.
-		l.INFO = log.New(multiWriter, generatePrefix(config.Include, "INFO"), 0)
-		l.WARN = log.New(multiWriter, generatePrefix(config.Include, "WARN"), 0)
-		l.ERROR = log.New(multiWriter, generatePrefix(config.Include, "ERROR"), 0)
-		l.DEBUG = log.New(multiWriter, generatePrefix(config.Include, "DEBUG"), 0)
-		l.TRACE = log.New(multiWriter, generatePrefix(config.Include, "TRACE"), 0)
-	} else {
-		// If no configuration is provided, fall back to a basic logger configuration.
-		flag := log.Lmsgprefix | log.LstdFlags | log.Lshortfile
-		l.INFO = log.New(multiWriter, "INFO ", flag)
-		l.WARN = log.New(multiWriter, "WARN ", flag)
-		l.ERROR = log.New(multiWriter, "ERROR ", flag)
-		l.DEBUG = log.New(multiWriter, "DEBUG ", flag)
-		l.TRACE = log.New(multiWriter, "TRACE ", flag)
+// derive returns an uninitialized child Logger that shares the destinations
+// and settings of l. The child's fields may be appended to without affecting l.
+func (l *Logger) derive() *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return &Logger{
+		file:       l.file,
+		levelFiles: l.levelFiles,
+		sinkFiles:  l.sinkFiles,
+		derived:    true,
+		exit:       l.exit,
+		now:        l.now,
+		ctxKeys:    l.ctxKeys,
+		tracing:    l.tracing,
+		observer:   l.observer,
+		limiter:    l.limiter,
+		async:      l.async,
+		filter:     l.filter,
+		config:     l.config,
+		values:     l.values,
+		leveled:    l.leveled,
+		hooks:      l.hooks,
+		sampler:    l.sampler,
+		dedup:      l.dedup,
+		onError:    l.onError,
+		syncLevel:  l.syncLevel,
+		callerSkip: l.callerSkip,
+		redactor:   l.redactor,
+		tail:       l.tail,
+		levelOuts:  l.levelOuts,
+		out:        l.out,
+		colorOut:   l.colorOut,
+		formatter:  l.formatter,
+		minLevel:   l.minLevel,
+		disabled:   maps.Clone(l.disabled),
+		fields:     l.fields[:len(l.fields):len(l.fields)],
 	}
+}
 
-	return l, nil
+// SetMinLevel changes the minimum level at runtime.
+// Loggers for levels below the threshold write to io.Discard, which makes their
+// calls nearly free; the remaining levels write to their configured destinations.
+func (l *Logger) SetMinLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.minLevel = level
+	l.applyLevels()
 }
 
-// generatePrefix generates a log prefix based on the specified syntax and log level.
-// syntax: Bitmask that determines which parts of the prefix are included.
-// level: The log level string (e.g., "INFO") to include in the prefix.
-// Returns the formatted log prefix string.
-func generatePrefix(syntax logSyntax, level string) string {
-	prefix := ""
+// SetLevelEnabled turns a single level on or off at runtime, independently of
+// the minimum level. A disabled level writes to io.Discard; enabling it again
+// restores its original destinations. A level is only written when it is both
+// enabled and at or above the minimum level.
+func (l *Logger) SetLevelEnabled(level Level, enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	// Include date and time if specified.
-	if syntax&DateTime != 0 {
-		prefix += time.Now().Format("2006-01-02 15:04:05") + " "
+	if l.disabled == nil {
+		l.disabled = make(map[Level]bool)
 	}
+	l.disabled[level] = !enabled
+	l.applyLevels()
+}
 
-	// Include the log level if specified.
-	if syntax&Loglevel != 0 {
-		prefix += level + " "
+// SetOutput replaces the stdout and custom Output destinations of every level
+// with w at runtime, for example to capture entries in a temporary file while
+// debugging. Log files and the system log keep receiving entries. A nil w
+// discards the entries that would have gone to stdout and Output. Loggers
+// previously derived through With keep their destinations.
+func (l *Logger) SetOutput(w io.Writer) {
+	if w == nil {
+		w = io.Discard
 	}
 
-	// Include file name and line number if specified.
-	if syntax&(ShortFileName|LongFileName) != 0 {
-		_, file, line, ok := runtime.Caller(2)
-		if ok {
-			if syntax&ShortFileName != 0 {
-				file = filepath.Base(file)
-			}
-			prefix += fmt.Sprintf("%s:%d ", file, line)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.out = &syncWriter{w: w}
+	l.colorOut = nil
+	l.leveled = nil
+	if _, ok := w.(LevelWriter); ok {
+		l.out = io.Discard
+		l.leveled = []*syncWriter{{w: w}}
+	}
+
+	l.rebuildWriters()
+}
+
+// SetInclude replaces the syntax elements included in every entry at runtime,
+// for example to add ShortFileName while debugging without recreating the
+// Logger. Entries written after the call use the new syntax. Loggers previously
+// derived through With keep their syntax.
+func (l *Logger) SetInclude(syntax logSyntax) error {
+	if err := syntax.validate(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.formatter.setSyntax(syntax)
+
+	l.rebuildWriters()
+	return nil
+}
+
+// rebuildWriters recreates the writer of every level after a destination has
+// changed. The level loggers are shared with callers, so their writers are
+// swapped instead of the loggers themselves.
+// The caller must hold l.mu.
+func (l *Logger) rebuildWriters() {
+	if !l.initialized() {
+		return
+	}
+
+	writers := make(map[Level]*levelWriter)
+	for _, level := range levels {
+		writers[level] = l.newLevelWriter(level)
+	}
+	l.writers = writers
+	l.applyLevels()
+}
+
+// applyLevels points every level logger either at its writer or at io.Discard,
+// depending on the minimum level and the individually disabled levels.
+// The caller must hold l.mu.
+func (l *Logger) applyLevels() {
+	if !l.initialized() {
+		return
+	}
+
+	for _, level := range levels {
+		if level < l.minLevel || l.disabled[level] {
+			l.levelLogger(level).SetOutput(io.Discard)
+		} else {
+			l.levelLogger(level).SetOutput(l.writers[level])
 		}
 	}
+}
 
-	return prefix
+// initialized reports whether l was created by a constructor or derived from
+// such a Logger, rather than declared as a zero value, whose settings such as
+// the clock are missing.
+func (l *Logger) initialized() bool {
+	return l.now != nil
+}
+
+// levelLogger returns the *log.Logger that handles the given level.
+func (l *Logger) levelLogger(level Level) *log.Logger {
+	switch level {
+	case TRACE:
+		return l.TRACE
+	case DEBUG:
+		return l.DEBUG
+	case INFO:
+		return l.INFO
+	case WARN:
+		return l.WARN
+	case ERROR:
+		return l.ERROR
+	case FATAL:
+		return l.FATAL
+	}
+	return nil
+}
+
+// setClock replaces the clock used for timestamps and daily rotation so that
+// time-dependent behavior can be tested deterministically. It recreates the
+// level loggers and therefore must be called before the Logger is used.
+func (l *Logger) setClock(now func() time.Time) {
+	l.mu.Lock()
+	l.now = now
+	l.formatter.start = now()
+	for _, f := range l.files() {
+		f.setClock(now)
+	}
+	l.mu.Unlock()
+
+	l.initLevels()
+}
+
+// FilePath returns the absolute path of the active main log file, which
+// carries the current date when daily rotation is enabled. It returns an empty
+// string when the Logger has no main log file, e.g. when logging only to stdout.
+func (l *Logger) FilePath() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return ""
+	}
+	return l.file.Path()
+}
+
+// Reopen closes the log files and opens them again at their configured paths.
+// External tools such as logrotate rename the log file and expect the process
+// to reopen it, typically on SIGHUP:
+//
+//	signal.Notify(hup, syscall.SIGHUP)
+//	go func() {
+//		for range hup {
+//			l.Reopen()
+//		}
+//	}()
+//
+// It is safe to call while other goroutines are logging.
+// Returns ErrClosed if the Logger has been closed.
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	var errs []error
+	for _, f := range l.files() {
+		errs = append(errs, f.Reopen())
+	}
+	return errors.Join(errs...)
+}
+
+// Rotate rotates the log files right away, regardless of MaxSizeMB and
+// RotateDaily: each active file is renamed to its first backup, following the
+// configured rotation and compression settings, and a fresh file is opened.
+// It is handy to wire up to a signal handler or an admin endpoint.
+// It is safe to call while other goroutines are logging.
+// Returns ErrClosed if the Logger has been closed.
+func (l *Logger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	var errs []error
+	for _, f := range l.files() {
+		errs = append(errs, f.Rotate())
+	}
+	return errors.Join(errs...)
+}
+
+// Flush writes entries held in the Async queue and in the write buffer (see
+// BufferSize) to their destinations.
+// It is a no-op for synchronous loggers without a log file.
+func (l *Logger) Flush() error {
+	l.async.wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for _, f := range l.files() {
+		errs = append(errs, f.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Sync flushes the write buffer and the log file to disk so that every entry
+// written so far is persisted, for example right before the process is about to crash.
+// It is a no-op for loggers without a log file.
+func (l *Logger) Sync() error {
+	l.async.wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for _, f := range l.files() {
+		errs = append(errs, f.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// files returns the main log file, the level files and the sink files, without duplicates.
+// The caller must hold l.mu.
+func (l *Logger) files() []*rotatingFile {
+	var files []*rotatingFile
+	seen := make(map[*rotatingFile]bool)
+	add := func(f *rotatingFile) {
+		if f != nil && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+
+	add(l.file)
+	for _, f := range l.levelFiles {
+		add(f)
+	}
+	for _, level := range levels {
+		for _, f := range l.sinkFiles[level] {
+			add(f)
+		}
+	}
+	return files
+}
+
+// Close closes the log files and the syslog connection opened by NewLogger.
+// After Close returns, entries are no longer written to them.
+// It is a no-op for loggers that only write to stdout. Loggers derived through
+// With leave the files open and only close the writer given to WithOutput.
+// Returns ErrClosed if the Logger has already been closed.
+func (l *Logger) Close() error {
+	// Report pending repeats and write queued entries while the destinations are still open.
+	if !l.derived {
+		l.dedup.flush()
+		l.async.close()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+	l.closed = true
+
+	// Files are owned by the Logger that opened them, not by its children.
+	var errs []error
+	if !l.derived {
+		for _, f := range l.files() {
+			errs = append(errs, f.Close())
+		}
+	}
+	for _, c := range l.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Start ties the lifetime of the Logger to ctx: once ctx is cancelled, the
+// Logger is closed like Close, stopping the background flusher and the async
+// worker after the buffered and queued entries have been written. This suits
+// services that manage their shutdown through a context:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer stop()
+//	log.Start(ctx)
+//
+// An error from closing is passed to the ErrorHandler.
+func (l *Logger) Start(ctx context.Context) {
+	context.AfterFunc(ctx, func() {
+		if err := l.Close(); !errors.Is(err, ErrClosed) {
+			l.onError.report(err)
+		}
+	})
+}
+
+// CloseWithTimeout closes the Logger like Close, flushing buffered entries
+// first, but gives up waiting after d so that a stuck writer cannot hang the
+// shutdown of the program. It returns ErrCloseTimeout when the deadline passes
+// before the entries are written; closing then carries on in the background.
+func (l *Logger) CloseWithTimeout(d time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Close()
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return ErrCloseTimeout
+	}
 }
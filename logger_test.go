@@ -0,0 +1,930 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMain clears LOG_LEVEL, commonly set in development shells and CI, so that
+// it cannot raise the minimum level of the loggers under test. TestLevelEnv
+// sets it on purpose.
+func TestMain(m *testing.M) {
+	os.Unsetenv(defaultLevelEnv)
+	os.Exit(m.Run())
+}
+
+// newBufferLogger returns a Logger created from config that writes to the
+// returned buffer through Output, and closes it when the test ends.
+func newBufferLogger(t *testing.T, config LogFileConfigs) (*Logger, *bytes.Buffer) {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	config.Output = buf
+	l, err := NewLogger(&config)
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l, buf
+}
+
+// lines returns the entries written to buf, without their trailing newlines.
+func lines(buf *bytes.Buffer) []string {
+	s := strings.TrimSuffix(buf.String(), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func TestTimestampPerEntry(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime})
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	l.setClock(func() time.Time { return now })
+
+	l.INFO.Println("first")
+	now = now.Add(time.Second)
+	l.INFO.Println("second")
+
+	got := lines(buf)
+	want := []string{"2024-06-01 12:00:00 first", "2024-06-01 12:00:01 second"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries %q, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// readFile returns the contents of the file at path.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading log file: %v", err)
+	}
+	return string(b)
+}
+
+func TestClose(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	l.INFO.Println("before close")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	l.INFO.Println("after close")
+
+	if got, want := readFile(t, filepath.Join(dir, "app.log")), "INFO before close\n"; got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+	if err := l.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestCloseStdoutOnly(t *testing.T) {
+	l, err := NewLogger(nil)
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close = %v, want nil", err)
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, MinLevel: INFO})
+
+	l.DEBUG.Println("debug")
+	l.ERROR.Println("error")
+	if got, want := buf.String(), "ERROR error\n"; got != want {
+		t.Errorf("with MinLevel INFO got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	l.SetMinLevel(DEBUG)
+	l.DEBUG.Println("debug")
+	l.SetMinLevel(ERROR)
+	l.WARN.Println("warn")
+	l.ERROR.Println("error")
+	if got, want := buf.String(), "DEBUG debug\nERROR error\n"; got != want {
+		t.Errorf("after SetMinLevel got %q, want %q", got, want)
+	}
+}
+
+func TestConcurrentLogging(t *testing.T) {
+	dir := t.TempDir()
+	buf := new(bytes.Buffer)
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Output: buf, Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	const goroutines, rounds = 50, 20
+	loggers := []*log.Logger{l.TRACE, l.DEBUG, l.INFO, l.WARN, l.ERROR}
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				for _, lg := range loggers {
+					lg.Printf("goroutine %d round %d", g, i)
+				}
+				l.SetMinLevel(TRACE)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Every destination holds every entry, each on a line of its own.
+	entry := regexp.MustCompile(`^(TRACE|DEBUG|INFO|WARN|ERROR) goroutine \d+ round \d+$`)
+	for name, output := range map[string]string{"Output": buf.String(), "file": readFile(t, filepath.Join(dir, "app.log"))} {
+		got := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if want := goroutines * rounds * len(loggers); len(got) != want {
+			t.Errorf("%s holds %d entries, want %d", name, len(got), want)
+		}
+		for _, line := range got {
+			if !entry.MatchString(line) {
+				t.Fatalf("%s holds a corrupted entry %q", name, line)
+			}
+		}
+	}
+}
+
+func TestFatal(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+	var codes []int
+	l.exit = func(code int) { codes = append(codes, code) }
+
+	l.Fatal("fatal")
+	l.Fatalf("fatal %d", 2)
+	l.FatalCode(3, "fatal code")
+
+	if got, want := buf.String(), "FATAL fatal\nFATAL fatal 2\nFATAL fatal code\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if want := []int{1, 1, 3}; !slices.Equal(codes, want) {
+		t.Errorf("exit codes = %v, want %v", codes, want)
+	}
+}
+
+func TestWith(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	child := l.With("req", "123")
+	child.With("user", "alice").INFO.Println("child")
+	l.INFO.Println("parent")
+
+	want := []string{"INFO child req=123 user=alice", "INFO parent"}
+	if got := lines(buf); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithJSON(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Format: FormatJSON, Include: Loglevel})
+
+	l.With("req", "123").INFO.Println("child")
+
+	if got, want := buf.String(), `{"level":"INFO","msg":"child","req":"123"}`+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestOutputWithFile(t *testing.T) {
+	dir := t.TempDir()
+	l, buf := newBufferLogger(t, LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel})
+
+	l.INFO.Println("hello")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got, want := buf.String(), "INFO hello\n"; got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "app.log")), "INFO hello\n"; got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestColorOnlyOnTerminal(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel, Color: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	// Stand in for a terminal on stdout, which NewLogger cannot detect in tests.
+	terminal := new(bytes.Buffer)
+	l.mu.Lock()
+	l.colorOut = terminal
+	l.rebuildWriters()
+	l.mu.Unlock()
+
+	l.ERROR.Println("failed")
+	l.Close()
+
+	if got, want := terminal.String(), "\x1b[31mERROR\x1b[0m failed\n"; got != want {
+		t.Errorf("terminal = %q, want %q", got, want)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "app.log")), "ERROR failed\n"; got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestColorMaxLineBytes(t *testing.T) {
+	tests := []struct {
+		maxLine        int
+		terminal, file string
+	}{
+		{12, "\x1b[31mERROR\x1b[0m failed...[truncated 6 bytes]\n", "ERROR failed...[truncated 6 bytes]\n"},
+		{3, "\x1b[31mERR\x1b[0m...[truncated 15 bytes]\n", "ERR...[truncated 15 bytes]\n"},
+	}
+	for _, tt := range tests {
+		l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, MaxLineBytes: tt.maxLine})
+
+		// Stand in for a terminal on stdout, which NewLogger cannot detect in tests.
+		terminal := new(bytes.Buffer)
+		l.mu.Lock()
+		l.colorOut = terminal
+		l.rebuildWriters()
+		l.mu.Unlock()
+
+		// The escape codes do not count towards the limit and are never cut.
+		l.Error("failed badly")
+		if got := terminal.String(); got != tt.terminal {
+			t.Errorf("MaxLineBytes %d: terminal = %q, want %q", tt.maxLine, got, tt.terminal)
+		}
+		if got := buf.String(); got != tt.file {
+			t.Errorf("MaxLineBytes %d: Output = %q, want %q", tt.maxLine, got, tt.file)
+		}
+	}
+}
+
+func TestFrozenClock(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime | ElapsedTime | Milliseconds})
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local)
+	now := start
+	l.setClock(func() time.Time { return now })
+
+	now = start.Add(1500 * time.Millisecond)
+	l.INFO.Println("elapsed")
+
+	if got, want := buf.String(), "+00:00:01.500 elapsed\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormattedHelpers(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	tests := []struct {
+		log  func(format string, args ...any)
+		want string
+	}{
+		{l.Tracef, "TRACE n=1"},
+		{l.Debugf, "DEBUG n=1"},
+		{l.Infof, "INFO n=1"},
+		{l.Warnf, "WARN n=1"},
+		{l.Errorf, "ERROR n=1"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		tt.log("n=%d", 1)
+		if got := buf.String(); got != tt.want+"\n" {
+			t.Errorf("got %q, want %q", got, tt.want+"\n")
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel, BufferSize: 4096})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	l.INFO.Println("synced")
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	if got, want := readFile(t, filepath.Join(dir, "app.log")), "INFO synced\n"; got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestSetLevelEnabled(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	l.SetLevelEnabled(TRACE, false)
+	l.TRACE.Println("hidden")
+	l.Trace("hidden")
+	if got := buf.String(); got != "" {
+		t.Errorf("disabled TRACE wrote %q", got)
+	}
+
+	l.SetLevelEnabled(TRACE, true)
+	l.TRACE.Println("shown")
+	if got, want := buf.String(), "TRACE shown\n"; got != want {
+		t.Errorf("re-enabled TRACE wrote %q, want %q", got, want)
+	}
+}
+
+func TestLevelFiles(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel,
+		LevelFiles: map[Level]string{ERROR: "error.log"}})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	l.INFO.Println("info")
+	l.ERROR.Println("error")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	assertFiles(t, dir, map[string]string{
+		"app.log":   "INFO info\n",
+		"error.log": "ERROR error\n",
+	})
+}
+
+func TestConflictingFileNames(t *testing.T) {
+	_, err := NewLogger(&LogFileConfigs{Stdout: true, Include: ShortFileName | LongFileName})
+	if err == nil || !strings.Contains(err.Error(), "ShortFileName and LongFileName") {
+		t.Errorf("NewLogger = %v, want an error naming both syntax elements", err)
+	}
+	if err := NewNopLogger().SetInclude(ShortFileName | LongFileName); err == nil {
+		t.Error("SetInclude accepted both ShortFileName and LongFileName")
+	}
+}
+
+func TestNewLoggerFromWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l, err := NewLoggerFromWriter(buf, Loglevel)
+	if err != nil {
+		t.Fatalf("NewLoggerFromWriter: %v", err)
+	}
+	defer l.Close()
+
+	l.TRACE.Println("trace")
+	l.ERROR.Println("error")
+
+	if got, want := buf.String(), "TRACE trace\nERROR error\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := l.FilePath(); got != "" {
+		t.Errorf("FilePath = %q, want no log file", got)
+	}
+}
+
+func TestLogFilePath(t *testing.T) {
+	wd := filepath.FromSlash("/srv/app")
+	abs := filepath.Join(t.TempDir(), "logs")
+
+	tests := []struct {
+		directory, filename, want string
+	}{
+		{"logs", "app.log", filepath.Join(wd, "logs", "app.log")},
+		{abs, "app.log", filepath.Join(abs, "app.log")},
+		{"logs", filepath.Join(abs, "app.log"), filepath.Join(abs, "app.log")},
+	}
+	for _, tt := range tests {
+		got := logFilePath(wd, &LogFileConfigs{Directory: tt.directory}, tt.filename)
+		if got != tt.want {
+			t.Errorf("logFilePath(%q, %q) = %q, want %q", tt.directory, tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestAbsoluteDirectory(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	if got, want := l.FilePath(), filepath.Join(dir, "app.log"); got != want {
+		t.Errorf("FilePath = %q, want %q", got, want)
+	}
+}
+
+func TestNopLogger(t *testing.T) {
+	l := NewNopLogger()
+
+	l.TRACE.Println("trace")
+	l.DEBUG.Println("debug")
+	l.INFO.Println("info")
+	l.WARN.Println("warn")
+	l.ERROR.Println("error")
+	for _, logE := range []func(...any) error{l.TraceE, l.DebugE, l.InfoE, l.WarnE, l.ErrorE} {
+		if err := logE("entry"); err != nil {
+			t.Errorf("logging to a nop Logger: %v", err)
+		}
+	}
+	for _, level := range levels {
+		if l.Enabled(level) {
+			t.Errorf("%v is enabled, want every level discarded", level)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestZeroValueLogger(t *testing.T) {
+	var zero Logger
+	var null *Logger
+	for _, l := range []*Logger{&zero, null} {
+		l.Trace("trace")
+		l.Debug("debug", "k", "v")
+		l.Info("info")
+		l.Warn("warn")
+		l.Errorf("error %d", 1)
+		if err := l.InfoE("info"); err != nil {
+			t.Errorf("InfoE: %v", err)
+		}
+		if l.Enabled(ERROR) {
+			t.Error("uninitialized Logger reports ERROR as enabled")
+		}
+	}
+
+	// Configuring a zero-value Logger leaves it, and its children, discarding entries.
+	zero.SetMinLevel(DEBUG)
+	zero.SetLevelEnabled(INFO, true)
+	zero.SetOutput(new(bytes.Buffer))
+	if err := zero.SetInclude(Loglevel); err != nil {
+		t.Errorf("SetInclude: %v", err)
+	}
+	zero.TailBuffer(10)
+	child := zero.With("k", "v")
+	child.Info("info")
+	zero.WithCallerSkip(1).Warn("warn")
+	zero.WithOutput(new(bytes.Buffer)).Error("error")
+	if child.Enabled(INFO) || zero.Enabled(INFO) {
+		t.Error("uninitialized Logger reports INFO as enabled")
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	l, before := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	// Swap the output while other goroutines keep logging.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info("background")
+			}
+		}()
+	}
+	after := new(bytes.Buffer)
+	l.SetOutput(after)
+	wg.Wait()
+
+	before.Reset()
+	after.Reset()
+	l.Info("swapped")
+	if got := before.String(); got != "" {
+		t.Errorf("old output received %q", got)
+	}
+	if got, want := after.String(), "INFO swapped\n"; got != want {
+		t.Errorf("new output = %q, want %q", got, want)
+	}
+}
+
+func TestDestinations(t *testing.T) {
+	tests := []struct {
+		name                 string
+		stdout               bool
+		filename             string
+		wantStdout, wantFile bool
+	}{
+		{name: "stdout only", stdout: true, wantStdout: true},
+		{name: "file only", filename: "app.log", wantFile: true},
+		{name: "both", stdout: true, filename: "app.log", wantStdout: true, wantFile: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Capture what the Logger writes to standard output.
+			terminal := new(bytes.Buffer)
+			saved := stdout
+			stdout = terminal
+			defer func() { stdout = saved }()
+
+			dir := t.TempDir()
+			l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: tt.filename, Stdout: tt.stdout, Include: Loglevel})
+			if err != nil {
+				t.Fatalf("NewLogger: %v", err)
+			}
+			l.INFO.Println("hello")
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			want := map[bool]string{true: "INFO hello\n"}
+			if got := terminal.String(); got != want[tt.wantStdout] {
+				t.Errorf("stdout = %q, want %q", got, want[tt.wantStdout])
+			}
+			files := map[string]string{}
+			if tt.wantFile {
+				files["app.log"] = want[true]
+			}
+			assertFiles(t, dir, files)
+		})
+	}
+}
+
+func TestSyncLevel(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel,
+		BufferSize: 4096, SyncLevel: ERROR})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+	path := filepath.Join(dir, "app.log")
+
+	l.INFO.Println("buffered")
+	if got := readFile(t, path); got != "" {
+		t.Errorf("INFO entry written before a flush: %q", got)
+	}
+	l.ERROR.Println("synced")
+	if got, want := readFile(t, path), "INFO buffered\nERROR synced\n"; got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	path := l.FilePath()
+	if !filepath.IsAbs(path) {
+		t.Errorf("FilePath = %q, want an absolute path", path)
+	}
+	l.INFO.Println("here")
+	if got, want := readFile(t, path), "INFO here\n"; got != want {
+		t.Errorf("file at FilePath = %q, want %q", got, want)
+	}
+	if got := NewNopLogger().FilePath(); got != "" {
+		t.Errorf("FilePath without a log file = %q, want empty", got)
+	}
+}
+
+func TestDebugEnabled(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, MinLevel: INFO})
+
+	built := false
+	if l.DebugEnabled() {
+		built = true
+		l.Debug("state")
+	}
+	if built || buf.Len() > 0 {
+		t.Error("DEBUG work done with MinLevel INFO")
+	}
+	if !l.InfoEnabled() || !l.ErrorEnabled() || l.TraceEnabled() {
+		t.Error("enabled levels do not match MinLevel INFO")
+	}
+}
+
+func TestLevelEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("APP_LOG_LEVEL", "debug")
+
+	tests := []struct {
+		levelEnv string
+		want     Level
+	}{
+		{"", WARN},
+		{"APP_LOG_LEVEL", DEBUG},
+		{"UNSET_LOG_LEVEL", ERROR},
+	}
+	for _, tt := range tests {
+		l, _ := newBufferLogger(t, LogFileConfigs{MinLevel: ERROR, LevelEnv: tt.levelEnv})
+		if got := l.Config().MinLevel; got != tt.want {
+			t.Errorf("with LevelEnv %q MinLevel = %v, want %v", tt.levelEnv, got, tt.want)
+		}
+	}
+
+	// An unknown level is ignored instead of failing.
+	t.Setenv("LOG_LEVEL", "loud")
+	l, _ := newBufferLogger(t, LogFileConfigs{MinLevel: ERROR})
+	if got := l.Config().MinLevel; got != ERROR {
+		t.Errorf("with an invalid LOG_LEVEL MinLevel = %v, want ERROR", got)
+	}
+}
+
+func TestExplicitDirectory(t *testing.T) {
+	if _, err := NewLogger(&LogFileConfigs{Filename: "app.log"}); err == nil || !strings.Contains(err.Error(), "needs a directory") {
+		t.Errorf("NewLogger without Directory = %v, want an error asking for one", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewLogger(&LogFileConfigs{Filename: path})
+	if err != nil {
+		t.Fatalf("NewLogger with an absolute file name: %v", err)
+	}
+	defer l.Close()
+	if got := l.FilePath(); got != path {
+		t.Errorf("FilePath = %q, want %q", got, path)
+	}
+}
+
+func TestFieldOrder(t *testing.T) {
+	for run := 0; run < 20; run++ {
+		for _, format := range []logFormat{FormatText, FormatJSON} {
+			l, buf := newBufferLogger(t, LogFileConfigs{Format: format})
+
+			l.With("zeta", 1).With("alpha", 2).Info("ordered", "mid", 3)
+
+			want := "ordered zeta=1 alpha=2 mid=3\n"
+			if format == FormatJSON {
+				want = `{"msg":"ordered","zeta":1,"alpha":2,"mid":3}` + "\n"
+			}
+			if got := buf.String(); got != want {
+				t.Fatalf("run %d, format %d: got %q, want %q", run, format, got, want)
+			}
+		}
+	}
+}
+
+func TestWithOutput(t *testing.T) {
+	dir := t.TempDir()
+	l, parent := newBufferLogger(t, LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel})
+
+	extra := new(bytes.Buffer)
+	child := l.WithOutput(extra)
+	child.Info("child")
+	l.Info("parent")
+	if err := child.Close(); err != nil {
+		t.Fatalf("closing the child: %v", err)
+	}
+	l.Info("after child closed")
+
+	if got, want := parent.String(), "INFO child\nINFO parent\nINFO after child closed\n"; got != want {
+		t.Errorf("parent output = %q, want %q", got, want)
+	}
+	if got, want := extra.String(), "INFO child\n"; got != want {
+		t.Errorf("child output = %q, want %q", got, want)
+	}
+	l.Close()
+	if got, want := readFile(t, filepath.Join(dir, "app.log")), parent.String(); got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestFallbackToStdout(t *testing.T) {
+	terminal := new(bytes.Buffer)
+	saved := stdout
+	stdout = terminal
+	defer func() { stdout = saved }()
+
+	// A regular file in place of the directory makes the log file impossible to create.
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := LogFileConfigs{Directory: filepath.Join(blocker, "logs"), Filename: "app.log", Include: Loglevel}
+
+	if _, err := NewLogger(&config); err == nil {
+		t.Fatal("NewLogger succeeded without FallbackToStdout")
+	}
+
+	var reported error
+	config.FallbackToStdout = true
+	config.ErrorHandler = func(err error) { reported = err }
+	l, err := NewLogger(&config)
+	if err != nil {
+		t.Fatalf("NewLogger with FallbackToStdout: %v", err)
+	}
+	defer l.Close()
+
+	l.INFO.Println("still logging")
+	if reported == nil {
+		t.Error("the failure was not reported to ErrorHandler")
+	}
+	if got, want := terminal.String(), "INFO still logging\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+}
+
+func TestWarningAliases(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	l.Warn("warn")
+	l.Warning("warning")
+	l.Warnf("warn%s", "f")
+	l.Warningf("warning%s", "f")
+
+	want := []string{"WARN warn", "WARN warning", "WARN warnf", "WARN warningf"}
+	if got := lines(buf); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInlineFields(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	l.Info("first", "user", "alice")
+	l.Info("second")
+	l.Info("odd", "user", "bob", "dangling")
+
+	want := []string{"INFO first user=alice", "INFO second", "INFO odd user=bob !BADKEY=dangling"}
+	if got := lines(buf); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// blockingWriter is an io.Writer whose writes wait until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+// Write waits for the release and then consumes p.
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestCloseWithTimeout(t *testing.T) {
+	stuck := blockingWriter{release: make(chan struct{})}
+	l, err := NewLogger(&LogFileConfigs{Output: stuck, Async: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	l.Info("pending")
+	if err := l.CloseWithTimeout(20 * time.Millisecond); !errors.Is(err, ErrCloseTimeout) {
+		t.Errorf("CloseWithTimeout with a stuck writer = %v, want ErrCloseTimeout", err)
+	}
+
+	// Closing carries on in the background once the writer recovers.
+	close(stuck.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for !errors.Is(l.Close(), ErrClosed) {
+		if time.Now().After(deadline) {
+			t.Fatal("the Logger was not closed after the writer recovered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime | Loglevel, SampleEvery: 2})
+
+	table := []byte("+----+\n| ok |\n+----+")
+	if err := l.Raw(INFO, table); err != nil {
+		t.Fatalf("Raw: %v", err)
+	}
+	if err := l.Raw(INFO, table); err != nil {
+		t.Fatalf("Raw: %v", err)
+	}
+
+	if got, want := buf.String(), string(table)+string(table); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	l, _ := newBufferLogger(t, LogFileConfigs{Directory: t.TempDir(), Filename: "app.log", RateLimit: 2.5, Async: true})
+
+	c := l.Config()
+	if c.TimeFormat != timeLayout || c.DirPerm != 0755 || c.FilePerm != 0644 || c.FieldSeparator != " " || c.LevelEnv != "LOG_LEVEL" {
+		t.Errorf("TimeFormat %q, DirPerm %v, FilePerm %v, FieldSeparator %q, LevelEnv %q: defaults not filled in",
+			c.TimeFormat, c.DirPerm, c.FilePerm, c.FieldSeparator, c.LevelEnv)
+	}
+	if c.FieldKeys != (FieldKeys{Time: "time", Level: "level", Message: "msg", File: "file", Line: "line", Func: "func"}) {
+		t.Errorf("FieldKeys = %+v, want the default names", c.FieldKeys)
+	}
+	if c.RateBurst != 3 || c.QueueSize != defaultQueueSize {
+		t.Errorf("RateBurst = %d, QueueSize = %d, want 3 and %d", c.RateBurst, c.QueueSize, defaultQueueSize)
+	}
+	if c.Filename != "app.log" || c.RateLimit != 2.5 {
+		t.Errorf("Filename = %q, RateLimit = %v, want the configured values", c.Filename, c.RateLimit)
+	}
+}
+
+func TestFatalCode(t *testing.T) {
+	for _, code := range []int{0, 2, 70} {
+		l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+		got := -1
+		l.exit = func(code int) { got = code }
+
+		l.FatalCode(code, "stopping", "code", code)
+
+		if got != code {
+			t.Errorf("FatalCode(%d) exited with %d", code, got)
+		}
+		if want := fmt.Sprintf("FATAL stopping code=%d\n", code); buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	}
+}
+
+func TestMustNewLogger(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustNewLogger did not panic without a destination")
+			}
+		}()
+		MustNewLogger(&LogFileConfigs{})
+	}()
+
+	l := MustNewLogger(&LogFileConfigs{Directory: t.TempDir(), Filename: "app.log"})
+	if l == nil {
+		t.Fatal("MustNewLogger returned nil")
+	}
+	l.Close()
+}
+
+func TestNoMkdir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	_, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", NoMkdir: true})
+	if err == nil || !strings.Contains(err.Error(), "must exist when NoMkdir is set") {
+		t.Errorf("NewLogger = %v, want an error about the missing directory", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("directory was created: %v", err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel,
+		Async: true, BufferSize: 4096, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.Start(ctx)
+
+	l.Info("queued")
+	cancel()
+
+	// The Logger closes in the background once the context is cancelled.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l.mu.Lock()
+		closed := l.closed
+		l.mu.Unlock()
+		if closed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the Logger was not closed after the context was cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := readFile(t, filepath.Join(dir, "app.log")), "INFO queued\n"; got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestNewTestLogger(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "error")
+	l, buf := NewTestLogger()
+	defer l.Close()
+
+	l.Trace("captured")
+
+	if got := buf.String(); !strings.Contains(got, " TRACE ") || !strings.HasSuffix(got, " captured\n") {
+		t.Errorf("buffer = %q, want the TRACE entry with the default prefix", got)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", DirPerm: 0750, FilePerm: 0640})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	for path, want := range map[string]os.FileMode{dir: 0750, filepath.Join(dir, "app.log"): 0640} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s has permissions %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Entry is a log entry as passed to an Encoder and captured by Observe.
+// Time: Moment the entry was written.
+// Level: Level of the entry.
+// Message: The message without its trailing newline.
+// Fields: Key/value pairs attached to the entry, in the order they were added.
+// File, Line: Source location of the log call, empty unless ShortFileName or LongFileName is included.
+// Function: Short name of the calling function, empty unless FuncName is included.
+// Goroutine: ID of the goroutine that wrote the entry, 0 unless GoroutineID is included.
+// formatter: Settings of the Logger that wrote the entry, used by MarshalJSON; nil for
+// entries built by callers.
+type Entry struct {
+	Time      time.Time
+	Level     Level
+	Message   string
+	Fields    []Field
+	File      string
+	Line      int
+	Function  string
+	Goroutine uint64
+	formatter *formatter
+}
+
+// Field is a key/value pair attached to an Entry.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// observer records the entries written by a Logger and the loggers derived from it.
+// It is safe for concurrent use.
+type observer struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// newObserver returns an observer, or nil when observing is disabled.
+func newObserver(enabled bool) *observer {
+	if !enabled {
+		return nil
+	}
+	return &observer{}
+}
+
+// record appends e, written at level and rendered with f, to the captured entries.
+func (o *observer) record(level Level, e entry, f *formatter) {
+	if o == nil {
+		return
+	}
+
+	captured := exportEntry(level, e, f)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.entries = append(o.entries, captured)
+}
+
+// exportEntry converts e, written at level and rendered with f, into an Entry.
+func exportEntry(level Level, e entry, f *formatter) Entry {
+	exported := Entry{
+		Time:      e.time,
+		Level:     level,
+		Message:   e.msg,
+		File:      e.file,
+		Line:      e.line,
+		Function:  e.function,
+		Goroutine: e.goroutine,
+		formatter: f,
+	}
+	if len(e.fields) > 0 {
+		exported.Fields = make([]Field, len(e.fields))
+		for i, f := range e.fields {
+			exported.Fields[i] = Field{Key: f.key, Value: f.value}
+		}
+	}
+	return exported
+}
+
+// Observed returns a copy of the entries written so far by a Logger configured
+// with Observe, including those of loggers derived from it through With. It
+// lets tests assert on structured entries instead of parsing formatted output.
+// It returns nil when Observe is not set.
+func (l *Logger) Observed() []Entry {
+	if l == nil || l.observer == nil {
+		return nil
+	}
+
+	l.observer.mu.Lock()
+	defer l.observer.mu.Unlock()
+
+	return append([]Entry(nil), l.observer.entries...)
+}
+
+// TestReporter is the part of testing.TB used by AssertMaxLevel; *testing.T,
+// *testing.B and *testing.F satisfy it. It keeps the testing package out of
+// the programs that import this package.
+type TestReporter interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertMaxLevel fails the test through t for every entry observed so far
+// above maxLevel, for example to ensure a scenario logged no ERROR:
+//
+//	defer log.AssertMaxLevel(t, logger.WARN)
+//
+// The Logger must be configured with Observe.
+func (l *Logger) AssertMaxLevel(t TestReporter, maxLevel Level) {
+	t.Helper()
+
+	if l == nil || l.observer == nil {
+		t.Errorf("logger: AssertMaxLevel requires a Logger configured with Observe")
+		return
+	}
+	for _, e := range l.Observed() {
+		if e.Level > maxLevel {
+			t.Errorf("logger: unexpected %s entry above %s: %s", e.Level, maxLevel, e.Message)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserved(t *testing.T) {
+	l, _ := newBufferLogger(t, LogFileConfigs{Observe: true})
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	l.setClock(func() time.Time { return now })
+
+	l.Info("started", "port", 8080)
+	l.With("req", "1").Error("failed")
+
+	got := l.Observed()
+	want := []Entry{
+		{Time: now, Level: INFO, Message: "started", Fields: []Field{{"port", 8080}}},
+		{Time: now, Level: ERROR, Message: "failed", Fields: []Field{{"req", "1"}}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("observed %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		got[i].formatter = nil
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// fakeReporter records the failures reported to it in place of a *testing.T.
+type fakeReporter struct {
+	failures []string
+}
+
+func (r *fakeReporter) Helper() {}
+
+func (r *fakeReporter) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertMaxLevel(t *testing.T) {
+	l, _ := newBufferLogger(t, LogFileConfigs{Observe: true})
+
+	l.Info("fine")
+	l.Warn("careful")
+	passing := new(fakeReporter)
+	l.AssertMaxLevel(passing, WARN)
+	if len(passing.failures) != 0 {
+		t.Errorf("AssertMaxLevel failed without an ERROR: %q", passing.failures)
+	}
+
+	l.Error("broken")
+	failing := new(fakeReporter)
+	l.AssertMaxLevel(failing, WARN)
+	if len(failing.failures) != 1 || !strings.Contains(failing.failures[0], "ERROR entry above WARN: broken") {
+		t.Errorf("AssertMaxLevel after an ERROR reported %q, want one failure naming it", failing.failures)
+	}
+
+	unobserved := new(fakeReporter)
+	NewNopLogger().AssertMaxLevel(unobserved, WARN)
+	if len(unobserved.failures) != 1 {
+		t.Errorf("AssertMaxLevel without Observe reported %q, want one failure", unobserved.failures)
+	}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	buf := new(bytes.Buffer)
+	l, err := New(
+		WithFile(dir, "app.log"),
+		WithWriter(buf),
+		WithLevel(WARN),
+		WithJSON(),
+		WithInclude(DateTime|Loglevel),
+		WithTimeFormat(time.RFC3339),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	l.setClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) })
+
+	l.INFO.Println("hidden")
+	l.WARN.Println("shown")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want := `{"time":"2024-06-01T12:00:00Z","level":"WARN","msg":"shown"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestNewDefaultsToStdout(t *testing.T) {
+	l, err := New(WithLevel(ERROR))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer l.Close()
+
+	if config := l.Config(); !config.Stdout || config.Include != defaultSyntax {
+		t.Errorf("Stdout = %v, Include = %v, want stdout with the default syntax", config.Stdout, config.Include)
+	}
+}
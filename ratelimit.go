@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimiter caps the number of entries written per second at each level with
+// a token bucket, dropping the excess and counting the dropped entries.
+// It is shared with the loggers derived through With and is safe for concurrent use.
+// rate: Number of tokens added to a bucket per second.
+// burst: Maximum number of tokens a bucket holds.
+// buckets: The token bucket of each level.
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	buckets [FATAL + 1]tokenBucket
+}
+
+// tokenBucket holds the tokens available to one level.
+// tokens: Number of entries that may be written right away.
+// last: Moment the tokens were last replenished, zero before the first entry.
+// dropped: Number of entries dropped since the last one written.
+type tokenBucket struct {
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	dropped int
+}
+
+// newRateLimiter returns a rateLimiter allowing rate entries per second with
+// bursts of up to burst entries, or nil when rate limiting is disabled.
+// A burst below 1 defaults to the rate rounded up, at least 1.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = max(1, int(rate+0.999999))
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst)}
+}
+
+// allow reports whether an entry at level arriving at now may be written. When
+// it may, dropped is the number of entries dropped before it that still have
+// to be reported. Fatal entries are never dropped.
+func (r *rateLimiter) allow(level Level, now time.Time) (ok bool, dropped int) {
+	if r == nil || level == FATAL || level < TRACE || level > FATAL {
+		return true, 0
+	}
+
+	b := &r.buckets[level]
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Replenish the tokens earned since the previous entry, up to the burst.
+	// Concurrent callers may arrive with slightly older times, which must not
+	// move the bucket back and earn the same interval twice.
+	if b.last.IsZero() {
+		b.tokens = r.burst
+		b.last = now
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(r.burst, b.tokens+elapsed*r.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		b.dropped++
+		return false, 0
+	}
+	b.tokens--
+
+	dropped, b.dropped = b.dropped, 0
+	return true, dropped
+}
+
+// droppedMessage returns the message reporting n entries dropped by the rate limit.
+func droppedMessage(n int) string {
+	return fmt.Sprintf("dropped %d entries exceeding the rate limit", n)
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, RateLimit: 10})
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	l.setClock(func() time.Time { return now })
+
+	// A flood within one instant is bounded by the burst.
+	for i := 0; i < 1000; i++ {
+		l.ERROR.Println("flood")
+	}
+	if got := len(lines(buf)); got != 10 {
+		t.Errorf("wrote %d entries of the flood, want the burst of 10", got)
+	}
+
+	// After a tenth of a second one more entry is let through and reports the drops.
+	buf.Reset()
+	now = now.Add(100 * time.Millisecond)
+	l.ERROR.Println("later")
+	l.ERROR.Println("dropped")
+
+	want := []string{"ERROR dropped 990 entries exceeding the rate limit", "ERROR later"}
+	if got := lines(buf); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
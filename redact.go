@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redacted replaces sensitive values in the output.
+const redacted = "***"
+
+// redactor masks sensitive data before an entry reaches the writers.
+// keys: Lower-cased field keys whose values are masked.
+// patterns: Expressions whose matches are masked in messages.
+type redactor struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// newRedactor returns a redactor for the given keys and patterns, or nil when
+// there is nothing to redact.
+func newRedactor(keys []string, patterns []*regexp.Regexp) *redactor {
+	if len(keys) == 0 && len(patterns) == 0 {
+		return nil
+	}
+
+	r := &redactor{keys: make(map[string]bool, len(keys)), patterns: patterns}
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = true
+	}
+	return r
+}
+
+// message replaces every match of the patterns in msg with "***".
+func (r *redactor) message(msg string) string {
+	if r == nil {
+		return msg
+	}
+	for _, p := range r.patterns {
+		msg = p.ReplaceAllString(msg, redacted)
+	}
+	return msg
+}
+
+// fields returns fields with the values of sensitive keys replaced with "***".
+// The slice is copied before it is modified, so shared fields are left intact.
+func (r *redactor) fields(fields []field) []field {
+	if r == nil || len(r.keys) == 0 {
+		return fields
+	}
+
+	copied := false
+	for i, f := range fields {
+		if !r.keys[strings.ToLower(f.key)] {
+			continue
+		}
+		if !copied {
+			fields = append([]field(nil), fields...)
+			copied = true
+		}
+		fields[i].value = redacted
+	}
+	return fields
+}
@@ -0,0 +1,20 @@
+package logger
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{
+		Include:        Loglevel,
+		RedactKeys:     []string{"password"},
+		RedactPatterns: []*regexp.Regexp{regexp.MustCompile(`token=\w+`)},
+	})
+
+	l.Info("login token=abc123", "user", "alice", "Password", "hunter2")
+
+	if got, want := buf.String(), "INFO login *** user=alice Password=***\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
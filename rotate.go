@@ -0,0 +1,650 @@
+package logger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// megabyte is the number of bytes in one MaxSizeMB unit.
+const megabyte = 1024 * 1024
+
+// dateLayout is the layout of the date suffix used by daily rotation.
+const dateLayout = "2006-01-02"
+
+// rotatingFile is an io.Writer over a log file that rotates the file once it
+// reaches a maximum size. Rotated files are renamed with a numeric suffix
+// (app.log -> app.log.1), shifting existing backups up by one.
+// With daily rotation enabled the active file carries the current date
+// (app-2024-06-01.log) and a new one is started on the first write of each day.
+// It is safe for concurrent use.
+// base: Configured path of the log file.
+// path: Path of the active log file.
+// file: Currently open active file, nil once closed or when reopening it failed.
+// closed: Set by Close, after which writes fail.
+// size: Number of bytes in the active file.
+// maxSize: Size in bytes that triggers rotation, 0 disables rotation.
+// maxBackups: Maximum number of rotated files kept, and with daily rotation of previous days
+// whose files are kept, 0 keeps all of them.
+// maxTotal: Size in bytes that the active and rotated files may occupy together, 0 disables the cap.
+// daily: Whether a new dated file is started every calendar day.
+// day: Date of the active file when daily is set.
+// now: Clock used to decide when a new day has started.
+// compress: Whether rotated files are gzipped in the background.
+// perm: Permissions of created files.
+// nameFunc: Names rotated files in place of the numbered scheme, nil for numbered backups.
+// rotated: Files rotated under names chosen by nameFunc, oldest first.
+// bufSize: Size of the write buffer in bytes, 0 writes directly to the file.
+// buf: Buffer in front of the active file, nil when buffering is disabled.
+// done: Closed by Close to stop the background flusher.
+// pending: Tracks compressions that are still running.
+// compressErr: First error reported by a background compression.
+type rotatingFile struct {
+	mu         sync.Mutex
+	base       string
+	path       string
+	file       *os.File
+	closed     bool
+	size       int64
+	maxSize    int64
+	maxBackups int
+	maxTotal   int64
+	daily      bool
+	day        string
+	now        func() time.Time
+	compress   bool
+	perm       os.FileMode
+	nameFunc   func(base string, t time.Time, seq int) string
+	rotated    []string
+	bufSize    int
+	buf        *bufio.Writer
+	done       chan struct{}
+
+	pending     sync.WaitGroup
+	errMu       sync.Mutex
+	compressErr error
+}
+
+// openRotatingFile opens (or creates) the log file at path for appending,
+// applying the rotation settings of config.
+// Returns the rotatingFile and an error if the file could not be opened.
+func openRotatingFile(path string, config *LogFileConfigs) (*rotatingFile, error) {
+	f := &rotatingFile{
+		base:       path,
+		path:       path,
+		maxSize:    int64(config.MaxSizeMB) * megabyte,
+		maxBackups: config.MaxBackups,
+		maxTotal:   int64(config.MaxTotalSizeMB) * megabyte,
+		daily:      config.RotateDaily,
+		now:        time.Now,
+		compress:   config.Compress,
+		perm:       config.FilePerm,
+		nameFunc:   config.RotateNameFunc,
+		bufSize:    config.BufferSize,
+	}
+	if f.perm == 0 {
+		f.perm = 0644
+	}
+	if f.daily {
+		f.day = f.now().Format(dateLayout)
+		f.path = datedName(path, f.day)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	// Periodically flush the buffer so entries do not linger in memory.
+	if f.bufSize > 0 && config.FlushInterval > 0 {
+		f.done = make(chan struct{})
+		go f.flushEvery(config.FlushInterval)
+	}
+	return f, nil
+}
+
+// open opens the active file and records its current size.
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, f.perm)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	if f.bufSize > 0 {
+		f.buf = bufio.NewWriterSize(file, f.bufSize)
+	}
+	return nil
+}
+
+// closeFile flushes the buffer and closes the active file.
+// The caller must hold f.mu.
+func (f *rotatingFile) closeFile() error {
+	err := f.flush()
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	f.file = nil
+	f.buf = nil
+	return err
+}
+
+// flush writes any buffered data to the active file.
+// The caller must hold f.mu.
+func (f *rotatingFile) flush() error {
+	if f.buf == nil {
+		return nil
+	}
+	return f.buf.Flush()
+}
+
+// flushEvery flushes the buffer at the given interval until Close is called.
+func (f *rotatingFile) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.Flush()
+		case <-f.done:
+			return
+		}
+	}
+}
+
+// Write writes p to the active file, rotating it first if p would push it past maxSize.
+// Returns the number of bytes written and any error encountered.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.ensureOpen(); err != nil {
+		return 0, err
+	}
+
+	// Start a new dated file on the first write of a new day.
+	if f.daily {
+		if day := f.now().Format(dateLayout); day != f.day {
+			if err := f.closeFile(); err != nil {
+				return 0, err
+			}
+			old := f.path
+			f.day = day
+			f.path = datedName(f.base, day)
+			if err := f.open(); err != nil {
+				return 0, err
+			}
+			if err := f.removeOldDays(); err != nil {
+				return 0, err
+			}
+			if err := f.removeExcess(); err != nil {
+				return 0, err
+			}
+			if f.compress && exists(old) {
+				f.compressInBackground(old)
+			}
+		}
+	}
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if f.buf != nil {
+		n, err = f.buf.Write(p)
+	} else {
+		n, err = f.file.Write(p)
+	}
+	f.size += int64(n)
+	return n, err
+}
+
+// ensureOpen opens the active file again if an earlier rotation or reopening
+// failed to, so that a transient failure does not stop file logging for good.
+// Returns os.ErrClosed once Close has been called.
+// The caller must hold f.mu.
+func (f *rotatingFile) ensureOpen() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return nil
+}
+
+// rotate closes the active file, renames it to a backup and opens a fresh
+// active file. Whether or not the backups could be renamed, the file at the
+// active path is opened again so that entries keep being written.
+// The caller must hold f.mu.
+func (f *rotatingFile) rotate() error {
+	name := ""
+	err := f.closeFile()
+	if err == nil {
+		// Backups are about to be renamed, so let running compressions finish first.
+		f.pending.Wait()
+
+		if f.nameFunc != nil {
+			name, err = f.renameNamed()
+		} else {
+			name, err = f.renameNumbered()
+		}
+	}
+
+	if oerr := f.open(); err == nil {
+		err = oerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.removeExcess(); err != nil {
+		return err
+	}
+	if f.compress && exists(name) {
+		f.compressInBackground(name)
+	}
+	return nil
+}
+
+// renameNumbered shifts the numbered backups up by one, dropping the oldest
+// beyond maxBackups, and renames the closed active file to the first backup.
+// Returns the name of the first backup.
+// The caller must hold f.mu.
+func (f *rotatingFile) renameNumbered() (string, error) {
+	// Find the highest backup that will be kept after shifting.
+	last := f.maxBackups
+	if last == 0 {
+		for last = 1; backupExists(f.path, last); last++ {
+		}
+	}
+
+	// Drop the oldest backup and shift the rest up by one, whether compressed or not.
+	for _, ext := range []string{"", gzExt} {
+		name := backupName(f.path, last) + ext
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		for i := last - 1; i >= 1; i-- {
+			err := os.Rename(backupName(f.path, i)+ext, backupName(f.path, i+1)+ext)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+		}
+	}
+
+	name := backupName(f.path, 1)
+	if err := os.Rename(f.path, name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// maxNameAttempts is the number of names asked from RotateNameFunc before rotation gives up.
+const maxNameAttempts = 1000
+
+// renameNamed renames the closed active file to the first free name returned
+// by nameFunc and deletes the oldest files it rotated beyond maxBackups.
+// Returns the name the file was renamed to.
+// The caller must hold f.mu.
+func (f *rotatingFile) renameNamed() (string, error) {
+	now := f.now()
+	name := ""
+	for seq := 1; name == ""; seq++ {
+		if seq > maxNameAttempts {
+			return "", fmt.Errorf("no free name to rotate %s to", f.path)
+		}
+		candidate := f.nameFunc(f.path, now, seq)
+		if !filepath.IsAbs(candidate) {
+			candidate = filepath.Join(filepath.Dir(f.path), candidate)
+		}
+		if candidate != f.path && !exists(candidate) && !exists(candidate+gzExt) {
+			name = candidate
+		}
+	}
+
+	if err := os.Rename(f.path, name); err != nil {
+		return "", err
+	}
+	f.rotated = append(f.rotated, name)
+	for f.maxBackups > 0 && len(f.rotated) > f.maxBackups {
+		for _, ext := range []string{"", gzExt} {
+			if err := os.Remove(f.rotated[0] + ext); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return name, err
+			}
+		}
+		f.rotated = f.rotated[1:]
+	}
+	return name, nil
+}
+
+// TimestampRotateName is a RotateNameFunc that names rotated files after the
+// moment of rotation, inserted before the extension of base
+// (app.log -> app.2024-06-01T12-00-00.log). Further files rotated within the
+// same second get a sequence number (app.2024-06-01T12-00-00.2.log).
+func TimestampRotateName(base string, t time.Time, seq int) string {
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext) + "." + t.Format("2006-01-02T15-04-05")
+	if seq > 1 {
+		name += "." + strconv.Itoa(seq)
+	}
+	return name + ext
+}
+
+// removeExcess deletes the oldest rotated files until the active and rotated
+// files together fit in maxTotal. The active file is never deleted.
+// The caller must hold f.mu.
+func (f *rotatingFile) removeExcess() error {
+	if f.maxTotal <= 0 {
+		return nil
+	}
+
+	// Backups are about to be deleted, so let running compressions finish first.
+	f.pending.Wait()
+
+	backups := f.backups()
+	total := f.size
+	for _, b := range backups {
+		total += b.Size()
+	}
+
+	// Delete the least recently modified backups first.
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime().Before(backups[j].ModTime())
+	})
+	dir := filepath.Dir(f.path)
+	for _, b := range backups {
+		if total <= f.maxTotal {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, b.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		total -= b.Size()
+	}
+	return nil
+}
+
+// removeOldDays deletes the files of the oldest previous days, numbered and
+// compressed copies included, so that the files of at most maxBackups days
+// are kept besides the current one. It only applies to daily rotation.
+// The caller must hold f.mu.
+func (f *rotatingFile) removeOldDays() error {
+	if !f.daily || f.maxBackups <= 0 {
+		return nil
+	}
+
+	// Files are about to be deleted, so let running compressions finish first.
+	f.pending.Wait()
+
+	// Group the files of previous days by the date in their name (app-2024-06-01.log.1.gz).
+	prefix := filepath.Base(strings.TrimSuffix(f.base, filepath.Ext(f.base))) + "-"
+	byDay := make(map[string][]string)
+	for _, b := range f.backups() {
+		name := b.Name()
+		if !strings.HasPrefix(name, prefix) || len(name) < len(prefix)+len(dateLayout) {
+			continue
+		}
+		if day := name[len(prefix) : len(prefix)+len(dateLayout)]; day != f.day {
+			byDay[day] = append(byDay[day], name)
+		}
+	}
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	dir := filepath.Dir(f.path)
+	for len(days) > f.maxBackups {
+		for _, name := range byDay[days[0]] {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		days = days[1:]
+	}
+	return nil
+}
+
+// backups returns the rotated files that belong to this log file: numbered
+// backups, compressed or not, with daily rotation the files of previous days,
+// and the files rotated under names chosen by RotateNameFunc.
+func (f *rotatingFile) backups() []os.FileInfo {
+	stem, pattern := f.base, f.base
+	if f.daily {
+		stem = datedName(f.base, dateLayout)
+		pattern = datedName(f.base, "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]")
+	}
+
+	matches, _ := filepath.Glob(pattern + "*")
+	var backups []os.FileInfo
+	for _, m := range matches {
+		if m == f.path || len(m) < len(stem) {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, m[:len(stem)]); !ok {
+			continue
+		}
+
+		// Accept only the bare file and its numbered copies.
+		rest := strings.TrimSuffix(m[len(stem):], gzExt)
+		if rest != "" && (rest[0] != '.' || strings.Trim(rest[1:], "0123456789") != "" || len(rest) == 1) {
+			continue
+		}
+
+		info, err := os.Stat(m)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		backups = append(backups, info)
+	}
+
+	// Files with custom names cannot be recognized, so only those rotated by this file are known.
+	for _, name := range f.rotated {
+		for _, candidate := range []string{name, name + gzExt} {
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+				backups = append(backups, info)
+			}
+		}
+	}
+	return backups
+}
+
+// compressInBackground gzips the file at path in a separate goroutine so the
+// write path is not blocked. Close waits for it to finish.
+func (f *rotatingFile) compressInBackground(path string) {
+	f.pending.Add(1)
+	go func() {
+		defer f.pending.Done()
+
+		if err := compressFile(path, f.perm); err != nil {
+			f.errMu.Lock()
+			if f.compressErr == nil {
+				f.compressErr = err
+			}
+			f.errMu.Unlock()
+		}
+	}()
+}
+
+// Flush writes any buffered data to the active file.
+func (f *rotatingFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.flush()
+}
+
+// Sync flushes the buffer and commits the contents of the active file to stable storage.
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	if err := f.flush(); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+// Reopen flushes and closes the active file and opens the file at its path
+// again, creating it if it has been moved away, for example by logrotate.
+// The file is opened even if closing the previous one failed.
+func (f *rotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	var err error
+	if f.file != nil {
+		err = f.closeFile()
+	}
+	if oerr := f.open(); err == nil {
+		err = oerr
+	}
+	return err
+}
+
+// Rotate rotates the active file right away, regardless of its size and age.
+func (f *rotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// Path returns the path of the active file, which changes daily with daily rotation.
+func (f *rotatingFile) Path() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.path
+}
+
+// setClock replaces the clock used to decide when a new day has started.
+func (f *rotatingFile) setClock(now func() time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+}
+
+// Close stops the background flusher, flushes the buffer, closes the active
+// file and waits for background compressions to finish.
+// Subsequent writes return os.ErrClosed.
+// Returns the error from closing the file or, failing that, the first compression error.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	if f.done != nil {
+		close(f.done)
+	}
+	var err error
+	if f.file != nil {
+		err = f.closeFile()
+	}
+
+	f.pending.Wait()
+	if err == nil {
+		f.errMu.Lock()
+		err = f.compressErr
+		f.errMu.Unlock()
+	}
+	return err
+}
+
+// backupName returns the name of the n-th rotated copy of the file at path.
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// datedName inserts the date before the extension of path (app.log -> app-2024-06-01.log).
+func datedName(path, day string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + day + ext
+}
+
+// backupExists reports whether the n-th rotated copy of the file at path exists,
+// either plain or compressed.
+func backupExists(path string, n int) bool {
+	return exists(backupName(path, n)) || exists(backupName(path, n)+gzExt)
+}
+
+// exists reports whether a file exists at path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// gzExt is the extension appended to compressed rotated files.
+const gzExt = ".gz"
+
+// compressFile gzips the file at path into path+".gz", created with perm, and
+// removes the original. The partially written archive is removed if compression fails.
+func compressFile(path string, perm os.FileMode) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+gzExt, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(path + gzExt)
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,434 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openTestFile opens a rotatingFile named app.log in a temporary directory
+// with the settings of config, rotating it once it exceeds maxSize bytes.
+func openTestFile(t *testing.T, config LogFileConfigs, maxSize int64) *rotatingFile {
+	t.Helper()
+
+	f, err := openRotatingFile(filepath.Join(t.TempDir(), "app.log"), &config)
+	if err != nil {
+		t.Fatalf("openRotatingFile: %v", err)
+	}
+	f.maxSize = maxSize
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// writeAll writes every string to w, failing the test on the first error.
+func writeAll(t *testing.T, w io.Writer, data ...string) {
+	t.Helper()
+
+	for _, s := range data {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("writing %q: %v", s, err)
+		}
+	}
+}
+
+// assertFiles checks that the files in dir have exactly the given contents.
+func assertFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading directory: %v", err)
+	}
+	if len(entries) != len(want) {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %q, want %d files", names, len(want))
+	}
+	for name, contents := range want {
+		if got := readFile(t, filepath.Join(dir, name)); got != contents {
+			t.Errorf("%s = %q, want %q", name, got, contents)
+		}
+	}
+}
+
+func TestSizeRotation(t *testing.T) {
+	f := openTestFile(t, LogFileConfigs{}, 10)
+
+	writeAll(t, f, "one\n", "two\n", "three\n", "four\n")
+
+	assertFiles(t, filepath.Dir(f.Path()), map[string]string{
+		"app.log":   "four\n",
+		"app.log.1": "three\n",
+		"app.log.2": "one\ntwo\n",
+	})
+}
+
+func TestSizeRotationMaxBackups(t *testing.T) {
+	f := openTestFile(t, LogFileConfigs{MaxBackups: 1}, 10)
+
+	writeAll(t, f, "one\n", "two\n", "three\n", "four\n")
+
+	assertFiles(t, filepath.Dir(f.Path()), map[string]string{
+		"app.log":   "four\n",
+		"app.log.1": "three\n",
+	})
+}
+
+func TestSizeRotationConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	const goroutines, entries = 8, 400
+	line := strings.Repeat("x", 1000)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				l.INFO.Println(line)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Every entry ends up intact in exactly one of the files.
+	files, _ := filepath.Glob(filepath.Join(dir, "app.log*"))
+	if len(files) < 3 {
+		t.Errorf("got %d files, want at least two rotations", len(files))
+	}
+	total := 0
+	for _, name := range files {
+		for _, entry := range strings.SplitAfter(readFile(t, name), "\n") {
+			if entry == "" {
+				continue
+			}
+			if entry != line+"\n" {
+				t.Fatalf("%s holds a corrupted entry %q", name, entry)
+			}
+			total++
+		}
+	}
+	if total != goroutines*entries {
+		t.Errorf("got %d entries, want %d", total, goroutines*entries)
+	}
+}
+
+func TestFailedRotationKeepsWriting(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", MaxBackups: 1, Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	// A non-empty directory in place of the oldest backup cannot be removed.
+	if err := os.MkdirAll(filepath.Join(dir, "app.log.1", "blocker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	l.INFO.Println("before")
+	if err := l.Rotate(); err == nil {
+		t.Fatal("Rotate succeeded, want an error")
+	}
+	if err := l.InfoE("after"); err != nil {
+		t.Errorf("InfoE after failed rotation: %v", err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Errorf("Reopen after failed rotation: %v", err)
+	}
+	if err := l.InfoE("reopened"); err != nil {
+		t.Errorf("InfoE after Reopen: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close after failed rotation: %v", err)
+	}
+
+	want := "INFO before\nINFO after\nINFO reopened\n"
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+func TestRotatedFileReopensAfterFailedOpen(t *testing.T) {
+	f := openTestFile(t, LogFileConfigs{}, 0)
+	dir := filepath.Dir(f.Path())
+
+	// Make the active path unopenable, as if it had been replaced by a directory.
+	if err := os.Remove(f.Path()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(f.Path(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err == nil {
+		t.Fatal("Reopen succeeded, want an error")
+	}
+	if _, err := f.Write([]byte("lost\n")); err == nil {
+		t.Fatal("Write succeeded without an open file")
+	}
+
+	// Once the path is usable again, writing resumes on its own.
+	if err := os.Remove(f.Path()); err != nil {
+		t.Fatal(err)
+	}
+	writeAll(t, f, "recovered\n")
+	assertFiles(t, dir, map[string]string{"app.log": "recovered\n"})
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := f.Write([]byte("closed\n")); err != os.ErrClosed {
+		t.Errorf("Write after Close = %v, want os.ErrClosed", err)
+	}
+}
+
+// logDays writes one entry per day for the given number of days, starting
+// today, through a Logger with daily rotation and the given MaxBackups.
+// Returns the directory of the log files and the dates of the days.
+func logDays(t *testing.T, days, maxBackups int) (string, []string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", RotateDaily: true, MaxBackups: maxBackups})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	now := time.Now()
+	l.setClock(func() time.Time { return now })
+
+	var dates []string
+	for i := 0; i < days; i++ {
+		dates = append(dates, now.Format(dateLayout))
+		l.INFO.Printf("day %d", i)
+		if got, want := l.FilePath(), filepath.Join(dir, "app-"+dates[i]+".log"); got != want {
+			t.Errorf("FilePath on day %d = %q, want %q", i, got, want)
+		}
+		now = now.AddDate(0, 0, 1)
+	}
+	return dir, dates
+}
+
+func TestDailyRotation(t *testing.T) {
+	dir, dates := logDays(t, 3, 0)
+
+	assertFiles(t, dir, map[string]string{
+		"app-" + dates[0] + ".log": "day 0\n",
+		"app-" + dates[1] + ".log": "day 1\n",
+		"app-" + dates[2] + ".log": "day 2\n",
+	})
+}
+
+func TestDailyRotationMaxBackups(t *testing.T) {
+	dir, dates := logDays(t, 4, 2)
+
+	assertFiles(t, dir, map[string]string{
+		"app-" + dates[1] + ".log": "day 1\n",
+		"app-" + dates[2] + ".log": "day 2\n",
+		"app-" + dates[3] + ".log": "day 3\n",
+	})
+}
+
+func TestCompress(t *testing.T) {
+	f := openTestFile(t, LogFileConfigs{Compress: true}, 10)
+
+	writeAll(t, f, "one\n", "two\n", "three\n")
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	gz, err := os.Open(f.Path() + ".1.gz")
+	if err != nil {
+		t.Fatalf("opening compressed backup: %v", err)
+	}
+	defer gz.Close()
+	r, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decompressing: %v", err)
+	}
+	if got, want := string(b), "one\ntwo\n"; got != want {
+		t.Errorf("decompressed backup = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(f.Path() + ".1"); !os.IsNotExist(err) {
+		t.Errorf("uncompressed backup left behind: %v", err)
+	}
+}
+
+func TestBufferedFlushInterval(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel,
+		BufferSize: 4096, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	path := filepath.Join(dir, "app.log")
+
+	// The background flusher writes the buffered entry without being asked.
+	l.INFO.Println("flushed")
+	deadline := time.Now().Add(5 * time.Second)
+	for readFile(t, path) != "INFO flushed\n" {
+		if time.Now().After(deadline) {
+			t.Fatalf("log file = %q after waiting for the flusher", readFile(t, path))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Close writes what is still buffered.
+	l.INFO.Println("closed")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got, want := readFile(t, path), "INFO flushed\nINFO closed\n"; got != want {
+		t.Errorf("log file = %q, want %q", got, want)
+	}
+}
+
+// benchmarkWrite measures logging an entry to a file with the given buffer size.
+func benchmarkWrite(b *testing.B, bufferSize int) {
+	l, err := NewLogger(&LogFileConfigs{Directory: b.TempDir(), Filename: "app.log", BufferSize: bufferSize})
+	if err != nil {
+		b.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.INFO.Println("benchmark entry")
+	}
+}
+
+func BenchmarkBufferedWrite(b *testing.B) {
+	benchmarkWrite(b, 64*1024)
+}
+
+func BenchmarkUnbufferedWrite(b *testing.B) {
+	benchmarkWrite(b, 0)
+}
+
+func TestMaxTotalSize(t *testing.T) {
+	f := openTestFile(t, LogFileConfigs{}, 10)
+	f.maxTotal = 20
+
+	writeAll(t, f, "aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n", "eeeeeeee\n")
+
+	// Each rotation keeps only the newest backups that fit in 20 bytes
+	// together with the freshly started active file.
+	assertFiles(t, filepath.Dir(f.Path()), map[string]string{
+		"app.log":   "eeeeeeee\n",
+		"app.log.1": "dddddddd\n",
+		"app.log.2": "cccccccc\n",
+	})
+}
+
+func TestReopenAfterRename(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	// Rename the file the way logrotate does, then reopen as on SIGHUP.
+	l.INFO.Println("old")
+	if err := os.Rename(filepath.Join(dir, "app.log"), filepath.Join(dir, "app.log.rotated")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	l.INFO.Println("new")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	assertFiles(t, dir, map[string]string{
+		"app.log":         "INFO new\n",
+		"app.log.rotated": "INFO old\n",
+	})
+}
+
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Include: Loglevel, Sinks: []Sink{
+		{Filename: "audit.log", Levels: []Level{INFO}},
+		{Filename: "app.log"},
+	}})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+
+	l.DEBUG.Println("debug")
+	l.INFO.Println("info")
+	l.ERROR.Println("error")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	assertFiles(t, dir, map[string]string{
+		"audit.log": "INFO info\n",
+		"app.log":   "DEBUG debug\nINFO info\nERROR error\n",
+	})
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	l.INFO.Println("before")
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	l.INFO.Println("after")
+
+	assertFiles(t, dir, map[string]string{
+		"app.log":   "INFO after\n",
+		"app.log.1": "INFO before\n",
+	})
+}
+
+func TestRotateNameFunc(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(&LogFileConfigs{Directory: dir, Filename: "app.log", Include: Loglevel, RotateNameFunc: TimestampRotateName})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+	l.setClock(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local) })
+
+	for _, msg := range []string{"first", "second", "third"} {
+		l.INFO.Println(msg)
+		if err := l.Rotate(); err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+	}
+
+	assertFiles(t, dir, map[string]string{
+		"app.log":                       "",
+		"app.2024-06-01T12-00-00.log":   "INFO first\n",
+		"app.2024-06-01T12-00-00.2.log": "INFO second\n",
+		"app.2024-06-01T12-00-00.3.log": "INFO third\n",
+	})
+}
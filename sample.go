@@ -0,0 +1,30 @@
+package logger
+
+import "sync/atomic"
+
+// sampler keeps 1 in every n entries per level and drops the rest.
+// It is shared with the loggers derived through With and is safe for concurrent use.
+// every: Keep one entry out of this many, 0 or 1 keeps every entry.
+// counts: Number of entries seen so far per level.
+type sampler struct {
+	every  uint64
+	counts [FATAL + 1]atomic.Uint64
+}
+
+// newSampler returns a sampler keeping 1 in every n entries, or nil when
+// sampling is disabled.
+func newSampler(every int) *sampler {
+	if every <= 1 {
+		return nil
+	}
+	return &sampler{every: uint64(every)}
+}
+
+// keep reports whether the next entry at level should be written.
+// Fatal entries are never dropped.
+func (s *sampler) keep(level Level) bool {
+	if s == nil || level == FATAL || level < TRACE || level > FATAL {
+		return true
+	}
+	return (s.counts[level].Add(1)-1)%s.every == 0
+}
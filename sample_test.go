@@ -0,0 +1,19 @@
+package logger
+
+import "testing"
+
+func TestSampleEvery(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel, SampleEvery: 10})
+
+	for i := 0; i < 100; i++ {
+		l.WARN.Printf("entry %d", i)
+	}
+
+	got := lines(buf)
+	if len(got) != 10 {
+		t.Fatalf("got %d entries, want 10", len(got))
+	}
+	if got[0] != "WARN entry 0" || got[9] != "WARN entry 90" {
+		t.Errorf("kept %q ... %q, want every tenth entry starting with the first", got[0], got[9])
+	}
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// maxStackDepth is the maximum number of frames captured by ErrorWithStack.
+const maxStackDepth = 64
+
+// stackTrace is a call stack captured at the point of logging, one
+// "function (file:line)" element per frame, innermost first. Text output
+// renders it as indented lines after the entry, JSON output as an array.
+type stackTrace []string
+
+// ErrorWithStack writes msg at ERROR level with err as the "error" field and
+// the call stack of the caller as the "stack" field. The stack is only captured
+// when ERROR entries are written, so the cost is paid solely by this call and
+// never by the other logging methods.
+func (l *Logger) ErrorWithStack(err error, msg string) {
+	if w := l.enabledWriter(ERROR); w != nil {
+		w.write(msg, []field{
+			{key: "error", value: err},
+			{key: "stack", value: callers(w.callerSkip)},
+		})
+	}
+}
+
+// callers returns the call stack above the frames of this package and the
+// standard log package, skipping skip additional frames like caller.
+func callers(skip int) stackTrace {
+	pcs := make([]uintptr, maxStackDepth+max(skip, 0))
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack stackTrace
+	found := false
+	for {
+		frame, more := frames.Next()
+		if found || !isLoggerFrame(frame.Function) {
+			found = true
+			if skip <= 0 {
+				stack = append(stack, fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line))
+			} else {
+				skip--
+			}
+		}
+		if !more || len(stack) == maxStackDepth {
+			return stack
+		}
+	}
+}
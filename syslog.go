@@ -0,0 +1,67 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// syslogPriorities maps each Level to the syslog severity it is logged with.
+var syslogPriorities = map[Level]syslog.Priority{
+	TRACE: syslog.LOG_DEBUG,
+	DEBUG: syslog.LOG_DEBUG,
+	INFO:  syslog.LOG_INFO,
+	WARN:  syslog.LOG_WARNING,
+	ERROR: syslog.LOG_ERR,
+	FATAL: syslog.LOG_CRIT,
+}
+
+// newSyslogWriters connects to the system log daemon using tag and returns a
+// writer for every level that logs with the matching severity, along with the
+// connection that has to be closed once logging is done.
+func newSyslogWriters(tag string) (map[Level]io.Writer, io.Closer, error) {
+	conn, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	writers := make(map[Level]io.Writer)
+	for _, level := range levels {
+		writers[level] = &syslogWriter{conn: conn, priority: syslogPriorities[level]}
+	}
+	return writers, conn, nil
+}
+
+// syslogWriter is an io.Writer that sends every entry to the system log with a fixed severity.
+// conn: Connection to the system log daemon.
+// priority: Severity the entries are logged with.
+type syslogWriter struct {
+	conn     *syslog.Writer
+	priority syslog.Priority
+}
+
+// Write sends p to the system log with the writer's severity.
+// Returns the number of bytes of p consumed and any error from the connection.
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	var err error
+	msg := string(p)
+
+	switch w.priority {
+	case syslog.LOG_DEBUG:
+		err = w.conn.Debug(msg)
+	case syslog.LOG_INFO:
+		err = w.conn.Info(msg)
+	case syslog.LOG_WARNING:
+		err = w.conn.Warning(msg)
+	case syslog.LOG_ERR:
+		err = w.conn.Err(msg)
+	default:
+		err = w.conn.Crit(msg)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
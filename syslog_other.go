@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"errors"
+	"io"
+)
+
+// newSyslogWriters reports that syslog is unavailable on this platform.
+func newSyslogWriters(tag string) (map[Level]io.Writer, io.Closer, error) {
+	return nil, nil, errors.New("syslog is not supported on this platform")
+}
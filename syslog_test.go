@@ -0,0 +1,51 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log/syslog"
+	"strings"
+	"testing"
+)
+
+func TestSyslogPriorities(t *testing.T) {
+	if got := syslogPriorities[ERROR]; got != syslog.LOG_ERR {
+		t.Errorf("ERROR is logged with priority %d, want LOG_ERR", got)
+	}
+
+	writers, conn, err := newSyslogWriters("logger-test")
+	if err != nil {
+		t.Skipf("system log unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	w, ok := writers[ERROR].(*syslogWriter)
+	if !ok {
+		t.Fatalf("ERROR writer is a %T, want *syslogWriter", writers[ERROR])
+	}
+	if w.priority != syslog.LOG_ERR {
+		t.Errorf("ERROR writer has priority %d, want LOG_ERR", w.priority)
+	}
+}
+
+func TestSyslogWithoutTime(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: DateTime | Loglevel})
+
+	// Stand in for the system log, which is not available in every test environment.
+	system := new(bytes.Buffer)
+	l.mu.Lock()
+	l.levelOuts = map[Level]io.Writer{ERROR: system}
+	l.rebuildWriters()
+	l.mu.Unlock()
+
+	l.ERROR.Println("failed")
+
+	if got, want := system.String(), "ERROR failed\n"; got != want {
+		t.Errorf("system log = %q, want %q", got, want)
+	}
+	if got := buf.String(); !strings.HasSuffix(got, " ERROR failed\n") || len(got) == len("ERROR failed\n") {
+		t.Errorf("Output = %q, want a timestamped entry", got)
+	}
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+)
+
+// ringBuffer is an io.Writer that retains the most recent entries written to it.
+// It is safe for concurrent use.
+// lines: Retained entries, used as a circular buffer once full.
+// next: Index in lines that receives the next entry once the buffer is full.
+type ringBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	size  int
+	next  int
+}
+
+// Write stores p, one entry without its trailing newline, evicting the oldest
+// entry when the buffer is full.
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.lines) < r.size {
+		r.lines = append(r.lines, line)
+	} else {
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % r.size
+	}
+	return len(p), nil
+}
+
+// snapshot returns the retained entries from oldest to newest.
+func (r *ringBuffer) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := make([]string, 0, len(r.lines))
+	lines = append(lines, r.lines[r.next:]...)
+	return append(lines, r.lines[:r.next]...)
+}
+
+// TailBuffer makes the Logger retain its n most recent entries in memory, as
+// they are written to the other destinations, for retrieval with Tail, e.g. by
+// an admin endpoint. Loggers derived through With afterwards share the buffer.
+// Calling TailBuffer again replaces the buffer; n <= 0 removes it.
+func (l *Logger) TailBuffer(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tail = nil
+	if n > 0 {
+		l.tail = &ringBuffer{size: n}
+	}
+	l.rebuildWriters()
+}
+
+// Tail returns the entries retained by TailBuffer from oldest to newest,
+// without their trailing newlines. It returns nil if TailBuffer has not been called.
+func (l *Logger) Tail() []string {
+	l.mu.Lock()
+	tail := l.tail
+	l.mu.Unlock()
+
+	if tail == nil {
+		return nil
+	}
+	return tail.snapshot()
+}
@@ -0,0 +1,23 @@
+package logger
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTail(t *testing.T) {
+	l, _ := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+	if got := l.Tail(); got != nil {
+		t.Errorf("Tail without a buffer = %q, want nil", got)
+	}
+
+	l.TailBuffer(3)
+	for i := 1; i <= 5; i++ {
+		l.INFO.Printf("entry %d", i)
+	}
+
+	want := []string{"INFO entry 3", "INFO entry 4", "INFO entry 5"}
+	if got := l.Tail(); !slices.Equal(got, want) {
+		t.Errorf("Tail = %q, want %q", got, want)
+	}
+}
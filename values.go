@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"reflect"
+	"time"
+)
+
+// ValueFormatter renders the field values of one type, for example byte counts
+// as "4.0 MiB". Create one with FormatType.
+// typ: Type of the values rendered.
+// fn: Returns the value written in place of the original one.
+type ValueFormatter struct {
+	typ reflect.Type
+	fn  func(any) any
+}
+
+// FormatType returns a ValueFormatter rendering field values of type T with
+// fn, in text and JSON output alike. T must be a concrete type, as values are
+// matched by their dynamic type:
+//
+//	type Bytes int64
+//
+//	config.ValueFormatters = []logger.ValueFormatter{
+//		logger.FormatType(func(b Bytes) any {
+//			return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20))
+//		}),
+//	}
+func FormatType[T any](fn func(T) any) ValueFormatter {
+	return ValueFormatter{
+		typ: reflect.TypeOf((*T)(nil)).Elem(),
+		fn:  func(v any) any { return fn(v.(T)) },
+	}
+}
+
+// valueFormatters renders field values by their type before an entry is
+// encoded. time.Duration values are rendered like 1.5s unless a formatter for
+// time.Duration is registered.
+type valueFormatters map[reflect.Type]func(any) any
+
+// newValueFormatters returns the formatters of list by type, or nil when list
+// is empty. Later formatters of the same type replace earlier ones.
+func newValueFormatters(list []ValueFormatter) valueFormatters {
+	if len(list) == 0 {
+		return nil
+	}
+
+	m := make(valueFormatters, len(list))
+	for _, f := range list {
+		if f.fn != nil {
+			m[f.typ] = f.fn
+		}
+	}
+	return m
+}
+
+// format returns the rendering of v and true if a formatter applies to it.
+func (m valueFormatters) format(v any) (any, bool) {
+	if len(m) > 0 && v != nil {
+		if fn, ok := m[reflect.TypeOf(v)]; ok {
+			return fn(v), true
+		}
+	}
+	if d, ok := v.(time.Duration); ok {
+		return d.String(), true
+	}
+	return nil, false
+}
+
+// fields returns fields with their values rendered by the matching formatters.
+// The slice is copied before it is modified, so shared fields are left intact.
+func (m valueFormatters) fields(fields []field) []field {
+	copied := false
+	for i, f := range fields {
+		v, ok := m.format(f.value)
+		if !ok {
+			continue
+		}
+		if !copied {
+			fields = append([]field(nil), fields...)
+			copied = true
+		}
+		fields[i].value = v
+	}
+	return fields
+}
@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// bytesCount is a custom type rendered by a registered formatter in tests.
+type bytesCount int64
+
+func TestValueFormatters(t *testing.T) {
+	mib := FormatType(func(b bytesCount) any { return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20)) })
+	l, buf := newBufferLogger(t, LogFileConfigs{ValueFormatters: []ValueFormatter{mib}})
+
+	l.Info("done", "took", 1500*time.Millisecond, "size", bytesCount(4<<20), "n", 3)
+
+	if got, want := buf.String(), "done took=1.5s size=\"4.0 MiB\" n=3\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
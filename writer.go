@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Writer returns an io.Writer that logs every line written to it at the given level,
+// formatted like any other entry of l. This allows output of the standard log
+// package or third-party libraries to be funneled into the Logger:
+//
+//	log.SetFlags(0)
+//	log.SetOutput(l.Writer(logger.WARN))
+//
+// Partial writes are buffered until a newline arrives, so a line split across
+// several writes still becomes a single entry.
+func (l *Logger) Writer(level Level) io.Writer {
+	return &lineWriter{l: l, level: level}
+}
+
+// lineWriter is the io.Writer returned by Logger.Writer.
+// l: Logger the lines are written to.
+// level: Level the lines are written at.
+// buf: Bytes of an incomplete line waiting for its newline.
+type lineWriter struct {
+	l     *Logger
+	level Level
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write logs every complete line in p and keeps a trailing partial line for the next call.
+// Returns len(p) and the first error reported while writing an entry.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(w.buf[:i]), "\r")
+		w.buf = w.buf[i+1:]
+		if werr := w.l.write(w.level, line, nil); err == nil {
+			err = werr
+		}
+	}
+	return len(p), err
+}
+
+// syncWriter serializes writes to a destination that may not be safe for
+// concurrent use, such as a bytes.Buffer passed as Output.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write writes p to the underlying writer while holding the lock.
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.w.Write(p)
+}
+
+// LevelWriter is implemented by destinations that route entries by severity,
+// such as clients of log aggregation services. A LevelWriter given as Output,
+// to SetOutput or to WithOutput receives every entry through WriteLevel along
+// with its level, instead of through Write.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level Level, p []byte) (n int, err error)
+}
+
+// writeLevel writes p at level to the underlying LevelWriter while holding the lock.
+func (w *syncWriter) writeLevel(level Level, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.w.(LevelWriter).WriteLevel(level, p)
+}
+
+// levelSink is the io.Writer through which the entries of one level reach a LevelWriter.
+// w: The LevelWriter, serialized by a syncWriter.
+// level: Level passed along with every entry.
+type levelSink struct {
+	w     *syncWriter
+	level Level
+}
+
+// Write passes p to the LevelWriter along with the sink's level.
+func (s levelSink) Write(p []byte) (int, error) {
+	return s.w.writeLevel(s.level, p)
+}
+
+// errorHandler passes write failures to the ErrorHandler configured by the user.
+// It is shared with the loggers derived through With and is safe for concurrent use.
+// fn: The configured callback, nil when none is set.
+// mu: Serializes the calls of fn, so concurrent failures are reported one after another.
+// owner: ID of the goroutine running fn, 0 when it is not running. Failures
+// caused by fn logging through the failing Logger are dropped instead of recursing.
+type errorHandler struct {
+	fn    func(error)
+	mu    sync.Mutex
+	owner atomic.Uint64
+}
+
+// newErrorHandler returns an errorHandler for fn, or nil when fn is nil.
+func newErrorHandler(fn func(error)) *errorHandler {
+	if fn == nil {
+		return nil
+	}
+	return &errorHandler{fn: fn}
+}
+
+// report calls the handler with err, waiting for calls reporting other failures
+// to return first. A failure raised by the handler itself on the goroutine it
+// runs on is dropped. The handler is called without holding any lock of the
+// Logger, and a panic raised by it is recovered.
+func (h *errorHandler) report(err error) {
+	if h == nil || err == nil {
+		return
+	}
+	id := goroutineID()
+	if h.owner.Load() == id {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.owner.Store(id)
+	defer h.owner.Store(0)
+	defer func() {
+		recover()
+	}()
+
+	h.fn(err)
+}
@@ -0,0 +1,196 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	l, buf := newBufferLogger(t, LogFileConfigs{Include: Loglevel})
+
+	std := log.New(l.Writer(WARN), "", 0)
+	std.Println("from log")
+
+	// Partial writes are held back until their line is complete.
+	w := l.Writer(WARN)
+	fmt.Fprint(w, "par")
+	if got := buf.String(); got != "WARN from log\n" {
+		t.Errorf("partial line written early: %q", got)
+	}
+	fmt.Fprint(w, "tial\r\n")
+
+	if got, want := buf.String(), "WARN from log\nWARN partial\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// failingWriter is an io.Writer whose writes all fail with err.
+type failingWriter struct {
+	err error
+}
+
+// Write reports the writer's error without consuming p.
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestWriteErrorPropagates(t *testing.T) {
+	errFull := errors.New("disk full")
+	l, err := NewLogger(&LogFileConfigs{Output: failingWriter{errFull}})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	for _, logE := range []func(...any) error{l.TraceE, l.DebugE, l.InfoE, l.WarnE, l.ErrorE} {
+		if err := logE("entry"); !errors.Is(err, errFull) {
+			t.Errorf("got %v, want %v", err, errFull)
+		}
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	errFull := errors.New("disk full")
+	var (
+		mu      sync.Mutex
+		reports []error
+		running atomic.Bool
+	)
+	var l *Logger
+	handler := func(err error) {
+		if !running.CompareAndSwap(false, true) {
+			t.Error("handler called concurrently")
+		}
+		defer running.Store(false)
+
+		// Failures caused by the handler's own logging are not reported again.
+		l.Error("logging failed")
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		reports = append(reports, err)
+		mu.Unlock()
+	}
+	l, err := NewLogger(&LogFileConfigs{Output: failingWriter{errFull}, ErrorHandler: handler})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	const writes = 50
+	var wg sync.WaitGroup
+	for i := 0; i < writes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info("entry")
+		}()
+	}
+	wg.Wait()
+
+	if len(reports) != writes {
+		t.Fatalf("handler called %d times, want %d", len(reports), writes)
+	}
+	for _, err := range reports {
+		if !errors.Is(err, errFull) {
+			t.Errorf("handler called with %v, want %v", err, errFull)
+		}
+	}
+}
+
+// callRecorder records the bytes of every Write call it receives.
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+// Write records p as one call.
+func (r *callRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, string(p))
+	return len(p), nil
+}
+
+func TestSingleWritePerEntry(t *testing.T) {
+	rec := new(callRecorder)
+	l, err := NewLogger(&LogFileConfigs{Output: rec, Include: Loglevel, Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	const goroutines, entries = 20, 50
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				l.Warn("entry", "goroutine", g, "i", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(rec.calls) != goroutines*entries {
+		t.Fatalf("got %d writes, want one per entry (%d)", len(rec.calls), goroutines*entries)
+	}
+	entry := regexp.MustCompile(`^\{"level":"WARN","msg":"entry","goroutine":\d+,"i":\d+\}\n$`)
+	for _, call := range rec.calls {
+		if !entry.MatchString(call) {
+			t.Fatalf("write %q is not a single well-formed entry", call)
+		}
+	}
+}
+
+// levelRecorder is a LevelWriter recording the level and bytes of every entry.
+type levelRecorder struct {
+	levels  []Level
+	entries []string
+	plain   int
+}
+
+// Write counts the entries written without a level.
+func (r *levelRecorder) Write(p []byte) (int, error) {
+	r.plain++
+	return len(p), nil
+}
+
+// WriteLevel records the entry along with its level.
+func (r *levelRecorder) WriteLevel(level Level, p []byte) (int, error) {
+	r.levels = append(r.levels, level)
+	r.entries = append(r.entries, string(p))
+	return len(p), nil
+}
+
+func TestLevelWriter(t *testing.T) {
+	rec := new(levelRecorder)
+	l, err := NewLogger(&LogFileConfigs{Output: rec, Include: Loglevel})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	l.Info("info")
+	l.Error("error")
+	l.With("k", "v").Warn("warn")
+
+	if want := []Level{INFO, ERROR, WARN}; !slices.Equal(rec.levels, want) {
+		t.Errorf("levels = %v, want %v", rec.levels, want)
+	}
+	if want := []string{"INFO info\n", "ERROR error\n", "WARN warn k=v\n"}; !slices.Equal(rec.entries, want) {
+		t.Errorf("entries = %q, want %q", rec.entries, want)
+	}
+	if rec.plain != 0 {
+		t.Errorf("Write called %d times, want every entry through WriteLevel", rec.plain)
+	}
+}